@@ -1,15 +1,29 @@
 package ai
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
 )
 
+const (
+	anthropicAPIURL    = "https://api.anthropic.com/v1/messages"
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 4096
+)
+
 type AnthropicProvider struct {
-	apiKey string
-	model  string
+	apiKey     string
+	model      string
+	apiURL     string
+	httpClient *http.Client
 }
 
 func NewAnthropicProvider(apiKey, model string) (*AnthropicProvider, error) {
@@ -22,20 +36,247 @@ func NewAnthropicProvider(apiKey, model string) (*AnthropicProvider, error) {
 	}
 
 	return &AnthropicProvider{
-		apiKey: apiKey,
-		model:  model,
+		apiKey:     apiKey,
+		model:      model,
+		apiURL:     anthropicAPIURL,
+		httpClient: &http.Client{Timeout: 120 * time.Second},
 	}, nil
 }
 
+// Close is a no-op; the Anthropic client holds no persistent connections
+func (p *AnthropicProvider) Close() error {
+	return nil
+}
+
 func (p *AnthropicProvider) GetProviderName() string {
 	return "anthropic"
 }
 
-func (p *AnthropicProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message) (*Response, error) {
-	// TODO: Implement Anthropic API integration
-	// For now, return a basic response
-	return &Response{
-		Content:      "Anthropic integration coming soon. Please use OpenAI provider for now.",
-		FinishReason: "stop",
-	}, nil
+// anthropicContentBlock is a single block of message content in the Messages API
+type anthropicContentBlock struct {
+	Type      string      `json:"type"`
+	Text      string      `json:"text,omitempty"`
+	ID        string      `json:"id,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	Input     interface{} `json:"input,omitempty"` // Always set for tool_use, even when empty
+	ToolUseID string      `json:"tool_use_id,omitempty"`
+	Content   string      `json:"content,omitempty"`
+	IsError   bool        `json:"is_error,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"` // "user" or "assistant"
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema interface{} `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Temperature *float32           `json:"temperature,omitempty"`
+	TopP        *float32           `json:"top_p,omitempty"`
+	TopK        *int32             `json:"top_k,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *AnthropicProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	req := anthropicRequest{
+		Model:     p.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    "You are a helpful AI assistant that can interact with CloudGenie infrastructure management platform. You have access to various tools to help manage cloud resources. When asked to perform operations, use the available tools to accomplish the task.",
+		Messages:  buildAnthropicMessages(prompt, conversationHistory),
+	}
+
+	// Apply per-request sampling parameters
+	if genConfig != nil {
+		req.Temperature = genConfig.Temperature
+		req.TopP = genConfig.TopP
+		req.TopK = genConfig.TopK
+		if genConfig.MaxTokens != nil {
+			req.MaxTokens = int(*genConfig.MaxTokens)
+		}
+	}
+
+	// Convert MCP tools to Anthropic tool definitions
+	for _, tool := range tools {
+		schema := tool.InputSchema
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object"}
+		}
+		req.Tools = append(req.Tools, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Anthropic request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Anthropic request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic API error: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Anthropic response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		var apiErr anthropicErrorResponse
+		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Error.Message != "" {
+			return nil, &HTTPStatusError{
+				StatusCode: httpResp.StatusCode,
+				Message:    fmt.Sprintf("Anthropic API error (%d %s): %s", httpResp.StatusCode, apiErr.Error.Type, apiErr.Error.Message),
+			}
+		}
+		return nil, &HTTPStatusError{
+			StatusCode: httpResp.StatusCode,
+			Message:    fmt.Sprintf("Anthropic API error (%d): %s", httpResp.StatusCode, string(respBody)),
+		}
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Anthropic response: %w", err)
+	}
+
+	response := &Response{
+		FinishReason: resp.StopReason,
+		Usage: &Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+
+	// Collect text and translate tool_use blocks into tool calls
+	var text []string
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			args, _ := block.Input.(map[string]interface{})
+			if args == nil {
+				args = make(map[string]interface{})
+			}
+			response.ToolCalls = append(response.ToolCalls, ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: args,
+			})
+		}
+	}
+	response.Content = strings.Join(text, "\n")
+
+	return response, nil
+}
+
+// buildAnthropicMessages maps conversation history and the current prompt onto
+// alternating user/assistant turns. Assistant tool calls become tool_use blocks
+// and their results are sent back as tool_result blocks in the following user turn
+func buildAnthropicMessages(prompt string, conversationHistory []Message) []anthropicMessage {
+	var messages []anthropicMessage
+
+	// Consecutive blocks for the same role are merged, since the API expects turns to alternate
+	appendBlocks := func(role string, blocks ...anthropicContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	// tool_result blocks must reference a tool_use from the preceding assistant turn
+	pendingToolUses := map[string]bool{}
+
+	for _, msg := range conversationHistory {
+		var blocks []anthropicContentBlock
+
+		if len(msg.ToolResults) > 0 {
+			for _, result := range msg.ToolResults {
+				if pendingToolUses[result.ToolCallID] {
+					blocks = append(blocks, anthropicContentBlock{
+						Type:      "tool_result",
+						ToolUseID: result.ToolCallID,
+						Content:   result.Content,
+						IsError:   result.IsError,
+					})
+					delete(pendingToolUses, result.ToolCallID)
+				} else {
+					blocks = append(blocks, anthropicContentBlock{Type: "text", Text: result.Content})
+				}
+			}
+			appendBlocks("user", blocks...)
+			continue
+		}
+
+		if msg.Content != "" {
+			blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+		}
+
+		switch msg.Role {
+		case "user":
+			appendBlocks("user", blocks...)
+		case "assistant":
+			pendingToolUses = map[string]bool{}
+			for _, tc := range msg.ToolCalls {
+				input := tc.Arguments
+				if input == nil {
+					input = make(map[string]interface{})
+				}
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Name,
+					Input: input,
+				})
+				pendingToolUses[tc.ID] = true
+			}
+			appendBlocks("assistant", blocks...)
+		}
+	}
+
+	appendBlocks("user", anthropicContentBlock{Type: "text", Text: prompt})
+
+	return messages
 }
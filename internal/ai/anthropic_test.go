@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// newTestAnthropicProvider returns a provider whose requests go to a test server answering
+// with reply, and the last request it received
+func newTestAnthropicProvider(t *testing.T, status int, reply string) (*AnthropicProvider, *anthropicRequest) {
+	t.Helper()
+	var req anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("x-api-key"); got != "test-key" {
+			t.Errorf("x-api-key = %q, want test-key", got)
+		}
+		if got := r.Header.Get("anthropic-version"); got != anthropicVersion {
+			t.Errorf("anthropic-version = %q, want %s", got, anthropicVersion)
+		}
+		req = anthropicRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := NewAnthropicProvider("test-key", "claude-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.apiURL = server.URL
+	return provider, &req
+}
+
+func TestAnthropicChatExtractsToolUse(t *testing.T) {
+	provider, req := newTestAnthropicProvider(t, http.StatusOK, `{
+		"content": [
+			{"type": "text", "text": "Creating the database."},
+			{"type": "tool_use", "id": "toolu_1", "name": "create_resource", "input": {"name": "db"}},
+			{"type": "tool_use", "id": "toolu_2", "name": "list_resources", "input": {}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 12, "output_tokens": 7}
+	}`)
+	tools := []*mcp.Tool{
+		{Name: "create_resource", Description: "Create a resource", InputSchema: map[string]interface{}{"type": "object"}},
+		{Name: "list_resources"},
+	}
+
+	resp, err := provider.Chat(context.Background(), "create a db", tools, nil, nil)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	wantCalls := []ToolCall{
+		{ID: "toolu_1", Name: "create_resource", Arguments: map[string]interface{}{"name": "db"}},
+		{ID: "toolu_2", Name: "list_resources", Arguments: map[string]interface{}{}},
+	}
+	if !reflect.DeepEqual(resp.ToolCalls, wantCalls) {
+		t.Errorf("ToolCalls = %+v, want %+v", resp.ToolCalls, wantCalls)
+	}
+	if resp.Content != "Creating the database." || resp.FinishReason != "tool_use" {
+		t.Errorf("Content, FinishReason = %q, %q", resp.Content, resp.FinishReason)
+	}
+	if want := (&Usage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19}); !reflect.DeepEqual(resp.Usage, want) {
+		t.Errorf("Usage = %+v, want %+v", resp.Usage, want)
+	}
+
+	if len(req.Tools) != 2 || req.Tools[0].Name != "create_resource" || req.Tools[0].Description != "Create a resource" {
+		t.Fatalf("request tools = %+v", req.Tools)
+	}
+	if req.Tools[1].InputSchema == nil {
+		t.Error("tool without a schema was sent without input_schema")
+	}
+	if req.Model != "claude-test" || req.MaxTokens != anthropicMaxTokens {
+		t.Errorf("Model, MaxTokens = %q, %d", req.Model, req.MaxTokens)
+	}
+}
+
+func TestAnthropicChatReturnsStatusError(t *testing.T) {
+	provider, _ := newTestAnthropicProvider(t, http.StatusTooManyRequests,
+		`{"type": "error", "error": {"type": "rate_limit_error", "message": "slow down"}}`)
+
+	_, err := provider.Chat(context.Background(), "hi", nil, nil, nil)
+	statusErr, ok := err.(*HTTPStatusError)
+	if !ok {
+		t.Fatalf("Chat error = %v (%T), want *HTTPStatusError", err, err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("StatusCode = %d, want %d", statusErr.StatusCode, http.StatusTooManyRequests)
+	}
+}
+
+func TestBuildAnthropicMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []Message
+		want    []anthropicMessage
+	}{
+		{
+			name: "prompt only",
+			want: []anthropicMessage{
+				{Role: "user", Content: []anthropicContentBlock{{Type: "text", Text: "next"}}},
+			},
+		},
+		{
+			name: "tool round trip",
+			history: []Message{
+				{Role: "user", Content: "create a db"},
+				{Role: "assistant", ToolCalls: []ToolCall{{ID: "toolu_1", Name: "create_resource", Arguments: map[string]interface{}{"name": "db"}}}},
+				{Role: "user", ToolResults: []ToolResult{{ToolCallID: "toolu_1", Content: "created", IsError: false}}},
+				{Role: "assistant", Content: "Done."},
+			},
+			want: []anthropicMessage{
+				{Role: "user", Content: []anthropicContentBlock{{Type: "text", Text: "create a db"}}},
+				{Role: "assistant", Content: []anthropicContentBlock{
+					{Type: "tool_use", ID: "toolu_1", Name: "create_resource", Input: map[string]interface{}{"name": "db"}},
+				}},
+				{Role: "user", Content: []anthropicContentBlock{{Type: "tool_result", ToolUseID: "toolu_1", Content: "created"}}},
+				{Role: "assistant", Content: []anthropicContentBlock{{Type: "text", Text: "Done."}}},
+				{Role: "user", Content: []anthropicContentBlock{{Type: "text", Text: "next"}}},
+			},
+		},
+		{
+			name: "orphaned tool result becomes text",
+			history: []Message{
+				{Role: "user", ToolResults: []ToolResult{{ToolCallID: "toolu_9", Content: "stale", IsError: true}}},
+			},
+			want: []anthropicMessage{
+				{Role: "user", Content: []anthropicContentBlock{{Type: "text", Text: "stale"}, {Type: "text", Text: "next"}}},
+			},
+		},
+		{
+			name: "tool call without arguments",
+			history: []Message{
+				{Role: "assistant", Content: "Listing.", ToolCalls: []ToolCall{{ID: "toolu_2", Name: "list_resources"}}},
+				{Role: "user", ToolResults: []ToolResult{{ToolCallID: "toolu_2", Content: "none", IsError: true}}},
+			},
+			want: []anthropicMessage{
+				{Role: "assistant", Content: []anthropicContentBlock{
+					{Type: "text", Text: "Listing."},
+					{Type: "tool_use", ID: "toolu_2", Name: "list_resources", Input: map[string]interface{}{}},
+				}},
+				{Role: "user", Content: []anthropicContentBlock{
+					{Type: "tool_result", ToolUseID: "toolu_2", Content: "none", IsError: true},
+					{Type: "text", Text: "next"},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildAnthropicMessages("next", tt.history); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildAnthropicMessages() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicChatSendsGenerationConfig(t *testing.T) {
+	provider, req := newTestAnthropicProvider(t, http.StatusOK, `{"content": [{"type": "text", "text": "ok"}], "stop_reason": "end_turn"}`)
+	temperature, topP, topK, maxTokens := float32(0), float32(0.5), int32(8), int32(256)
+
+	genConfig := &GenerationConfig{Temperature: &temperature, TopP: &topP, TopK: &topK, MaxTokens: &maxTokens}
+	if _, err := provider.Chat(context.Background(), "hi", nil, nil, genConfig); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if req.Temperature == nil || *req.Temperature != 0 || req.TopP == nil || *req.TopP != 0.5 ||
+		req.TopK == nil || *req.TopK != 8 || req.MaxTokens != 256 {
+		t.Errorf("request sampling = temperature %v, top_p %v, top_k %v, max_tokens %d",
+			req.Temperature, req.TopP, req.TopK, req.MaxTokens)
+	}
+
+	if _, err := provider.Chat(context.Background(), "hi", nil, nil, nil); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if req.Temperature != nil || req.TopP != nil || req.TopK != nil || req.MaxTokens != anthropicMaxTokens {
+		t.Errorf("nil config sent overrides: %+v", req)
+	}
+}
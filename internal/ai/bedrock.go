@@ -0,0 +1,233 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// bedrockConverseAPI is the subset of the Bedrock Runtime client used by the provider
+type bedrockConverseAPI interface {
+	Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error)
+}
+
+type BedrockProvider struct {
+	client  bedrockConverseAPI
+	modelID string
+}
+
+// NewBedrockProvider creates a provider for a Bedrock model, using credentials from the default AWS chain
+func NewBedrockProvider(region, modelID string) (*BedrockProvider, error) {
+	if region == "" {
+		return nil, fmt.Errorf("Bedrock region is required")
+	}
+
+	if modelID == "" {
+		modelID = "anthropic.claude-3-5-sonnet-20240620-v1:0" // Default to Claude 3.5 Sonnet on Bedrock
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return &BedrockProvider{
+		client:  bedrockruntime.NewFromConfig(awsCfg),
+		modelID: modelID,
+	}, nil
+}
+
+// Close is a no-op; the Bedrock client holds no persistent connections
+func (p *BedrockProvider) Close() error {
+	return nil
+}
+
+func (p *BedrockProvider) GetProviderName() string {
+	return "bedrock"
+}
+
+func (p *BedrockProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	input := &bedrockruntime.ConverseInput{
+		ModelId:  aws.String(p.modelID),
+		Messages: buildBedrockMessages(prompt, conversationHistory),
+		System: []types.SystemContentBlock{
+			&types.SystemContentBlockMemberText{
+				Value: "You are a helpful AI assistant that can interact with CloudGenie infrastructure management platform. You have access to various tools to help manage cloud resources. When asked to perform operations, use the available tools to accomplish the task.",
+			},
+		},
+	}
+
+	// Apply per-request sampling parameters (top_k is model-specific in Bedrock and isn't sent)
+	if genConfig != nil {
+		input.InferenceConfig = &types.InferenceConfiguration{
+			Temperature: genConfig.Temperature,
+			TopP:        genConfig.TopP,
+			MaxTokens:   genConfig.MaxTokens,
+		}
+	}
+
+	// Convert MCP tools to Converse tool specifications
+	if len(tools) > 0 {
+		toolConfig := &types.ToolConfiguration{}
+		for _, tool := range tools {
+			schema := tool.InputSchema
+			if schema == nil {
+				schema = map[string]interface{}{"type": "object"}
+			}
+			spec := types.ToolSpecification{
+				Name:        aws.String(tool.Name),
+				InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(schema)},
+			}
+			if tool.Description != "" {
+				spec.Description = aws.String(tool.Description)
+			}
+			toolConfig.Tools = append(toolConfig.Tools, &types.ToolMemberToolSpec{Value: spec})
+		}
+		input.ToolConfig = toolConfig
+	}
+
+	output, err := p.client.Converse(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("Bedrock API error: %w", err)
+	}
+
+	message, ok := output.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("no response from Bedrock")
+	}
+
+	response := &Response{
+		FinishReason: string(output.StopReason),
+		Usage:        &Usage{},
+	}
+	if output.Usage != nil {
+		response.Usage.PromptTokens = int(aws.ToInt32(output.Usage.InputTokens))
+		response.Usage.CompletionTokens = int(aws.ToInt32(output.Usage.OutputTokens))
+		response.Usage.TotalTokens = int(aws.ToInt32(output.Usage.TotalTokens))
+	}
+
+	// Collect text and translate toolUse blocks into tool calls
+	var text []string
+	for _, block := range message.Value.Content {
+		switch block := block.(type) {
+		case *types.ContentBlockMemberText:
+			text = append(text, block.Value)
+		case *types.ContentBlockMemberToolUse:
+			args, err := bedrockToolInput(block.Value.Input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+			}
+			response.ToolCalls = append(response.ToolCalls, ToolCall{
+				ID:        aws.ToString(block.Value.ToolUseId),
+				Name:      aws.ToString(block.Value.Name),
+				Arguments: args,
+			})
+		}
+	}
+	response.Content = strings.Join(text, "\n")
+
+	return response, nil
+}
+
+// bedrockToolInput decodes a toolUse input document into tool call arguments. The document is
+// marshaled back to JSON first, since smithy documents can't unmarshal into an untyped map
+func bedrockToolInput(input document.Interface) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	if input == nil {
+		return args, nil
+	}
+	raw, err := input.MarshalSmithyDocument()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+	return args, nil
+}
+
+// buildBedrockMessages maps conversation history and the current prompt onto alternating
+// user/assistant Converse messages, with tool calls as toolUse blocks and their results
+// as toolResult blocks in the following user turn
+func buildBedrockMessages(prompt string, conversationHistory []Message) []types.Message {
+	var messages []types.Message
+
+	// Consecutive blocks for the same role are merged, since Converse expects turns to alternate
+	appendBlocks := func(role types.ConversationRole, blocks ...types.ContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, types.Message{Role: role, Content: blocks})
+	}
+
+	// toolResult blocks must reference a toolUse from the preceding assistant turn
+	pendingToolUses := map[string]bool{}
+
+	for _, msg := range conversationHistory {
+		var blocks []types.ContentBlock
+
+		if len(msg.ToolResults) > 0 {
+			for _, result := range msg.ToolResults {
+				if !pendingToolUses[result.ToolCallID] {
+					blocks = append(blocks, &types.ContentBlockMemberText{Value: result.Content})
+					continue
+				}
+				status := types.ToolResultStatusSuccess
+				if result.IsError {
+					status = types.ToolResultStatusError
+				}
+				blocks = append(blocks, &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+					ToolUseId: aws.String(result.ToolCallID),
+					Content:   []types.ToolResultContentBlock{&types.ToolResultContentBlockMemberText{Value: result.Content}},
+					Status:    status,
+				}})
+				delete(pendingToolUses, result.ToolCallID)
+			}
+			appendBlocks(types.ConversationRoleUser, blocks...)
+			continue
+		}
+
+		if msg.Content != "" {
+			blocks = append(blocks, &types.ContentBlockMemberText{Value: msg.Content})
+		}
+
+		switch msg.Role {
+		case "user":
+			appendBlocks(types.ConversationRoleUser, blocks...)
+		case "assistant":
+			pendingToolUses = map[string]bool{}
+			for _, tc := range msg.ToolCalls {
+				input := tc.Arguments
+				if input == nil {
+					input = make(map[string]interface{})
+				}
+				blocks = append(blocks, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String(tc.ID),
+					Name:      aws.String(tc.Name),
+					Input:     document.NewLazyDocument(input),
+				}})
+				pendingToolUses[tc.ID] = true
+			}
+			appendBlocks(types.ConversationRoleAssistant, blocks...)
+		}
+	}
+
+	appendBlocks(types.ConversationRoleUser, &types.ContentBlockMemberText{Value: prompt})
+
+	return messages
+}
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// fakeConverseClient records the last Converse input and answers with output or err
+type fakeConverseClient struct {
+	input  *bedrockruntime.ConverseInput
+	output *bedrockruntime.ConverseOutput
+	err    error
+}
+
+func (c *fakeConverseClient) Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error) {
+	c.input = params
+	return c.output, c.err
+}
+
+func TestBedrockChatExtractsToolUse(t *testing.T) {
+	client := &fakeConverseClient{output: &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role: types.ConversationRoleAssistant,
+			Content: []types.ContentBlock{
+				&types.ContentBlockMemberText{Value: "Creating the database."},
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("tooluse_1"),
+					Name:      aws.String("create_resource"),
+					Input:     document.NewLazyDocument(map[string]interface{}{"name": "db"}),
+				}},
+			},
+		}},
+		StopReason: types.StopReasonToolUse,
+		Usage:      &types.TokenUsage{InputTokens: aws.Int32(12), OutputTokens: aws.Int32(7), TotalTokens: aws.Int32(19)},
+	}}
+	provider := &BedrockProvider{client: client, modelID: "anthropic.claude-test"}
+	tools := []*mcp.Tool{{Name: "create_resource", Description: "Create a resource"}, {Name: "list_resources"}}
+	maxTokens := int32(256)
+
+	resp, err := provider.Chat(context.Background(), "create a db", tools, nil, &GenerationConfig{MaxTokens: &maxTokens})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	wantCalls := []ToolCall{{ID: "tooluse_1", Name: "create_resource", Arguments: map[string]interface{}{"name": "db"}}}
+	if !reflect.DeepEqual(resp.ToolCalls, wantCalls) {
+		t.Errorf("ToolCalls = %+v, want %+v", resp.ToolCalls, wantCalls)
+	}
+	if resp.Content != "Creating the database." || resp.FinishReason != "tool_use" {
+		t.Errorf("Content, FinishReason = %q, %q", resp.Content, resp.FinishReason)
+	}
+	if want := (&Usage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19}); !reflect.DeepEqual(resp.Usage, want) {
+		t.Errorf("Usage = %+v, want %+v", resp.Usage, want)
+	}
+
+	input := client.input
+	if aws.ToString(input.ModelId) != "anthropic.claude-test" {
+		t.Errorf("ModelId = %q", aws.ToString(input.ModelId))
+	}
+	if input.InferenceConfig == nil || aws.ToInt32(input.InferenceConfig.MaxTokens) != 256 {
+		t.Errorf("InferenceConfig = %+v, want MaxTokens 256", input.InferenceConfig)
+	}
+	if input.ToolConfig == nil || len(input.ToolConfig.Tools) != 2 {
+		t.Fatalf("ToolConfig = %+v, want 2 tools", input.ToolConfig)
+	}
+	spec, ok := input.ToolConfig.Tools[0].(*types.ToolMemberToolSpec)
+	if !ok || aws.ToString(spec.Value.Name) != "create_resource" || aws.ToString(spec.Value.Description) != "Create a resource" {
+		t.Errorf("first tool = %+v", input.ToolConfig.Tools[0])
+	}
+}
+
+func TestBedrockChatWrapsClientErrors(t *testing.T) {
+	provider := &BedrockProvider{client: &fakeConverseClient{err: errors.New("throttled")}, modelID: "m"}
+	if _, err := provider.Chat(context.Background(), "hi", nil, nil, nil); err == nil {
+		t.Fatal("Chat returned no error for a failed Converse call")
+	}
+}
+
+func TestBedrockToolInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input document.Interface
+		want  map[string]interface{}
+	}{
+		{"nil input", nil, map[string]interface{}{}},
+		{"nested arguments", document.NewLazyDocument(map[string]interface{}{"name": "db", "size": 2, "tags": []string{"prod"}}),
+			map[string]interface{}{"name": "db", "size": float64(2), "tags": []interface{}{"prod"}}},
+		{"null document", document.NewLazyDocument(nil), map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bedrockToolInput(tt.input)
+			if err != nil {
+				t.Fatalf("bedrockToolInput: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildBedrockMessages(t *testing.T) {
+	history := []Message{
+		{Role: "user", Content: "create a db"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "tooluse_1", Name: "create_resource", Arguments: map[string]interface{}{"name": "db"}}}},
+		{Role: "user", ToolResults: []ToolResult{
+			{ToolCallID: "tooluse_1", Content: "quota exceeded", IsError: true},
+			{ToolCallID: "tooluse_9", Content: "stale"},
+		}},
+	}
+
+	messages := buildBedrockMessages("next", history)
+
+	wantRoles := []types.ConversationRole{types.ConversationRoleUser, types.ConversationRoleAssistant, types.ConversationRoleUser}
+	if len(messages) != len(wantRoles) {
+		t.Fatalf("got %d messages, want %d", len(messages), len(wantRoles))
+	}
+	for i, role := range wantRoles {
+		if messages[i].Role != role {
+			t.Errorf("message %d role = %s, want %s", i, messages[i].Role, role)
+		}
+	}
+
+	toolUse, ok := messages[1].Content[0].(*types.ContentBlockMemberToolUse)
+	if !ok || aws.ToString(toolUse.Value.ToolUseId) != "tooluse_1" || aws.ToString(toolUse.Value.Name) != "create_resource" {
+		t.Fatalf("assistant block = %+v, want a create_resource toolUse", messages[1].Content[0])
+	}
+	args, err := bedrockToolInput(toolUse.Value.Input)
+	if err != nil || args["name"] != "db" {
+		t.Errorf("toolUse input = %v (%v), want name=db", args, err)
+	}
+
+	// The final user turn holds the tool result, the orphaned result as text, and the prompt
+	last := messages[2].Content
+	if len(last) != 3 {
+		t.Fatalf("final user turn has %d blocks, want 3", len(last))
+	}
+	result, ok := last[0].(*types.ContentBlockMemberToolResult)
+	if !ok || aws.ToString(result.Value.ToolUseId) != "tooluse_1" || result.Value.Status != types.ToolResultStatusError {
+		t.Errorf("first block = %+v, want an error toolResult for tooluse_1", last[0])
+	}
+	if text, ok := last[1].(*types.ContentBlockMemberText); !ok || text.Value != "stale" {
+		t.Errorf("orphaned tool result = %+v, want text", last[1])
+	}
+	if text, ok := last[2].(*types.ContentBlockMemberText); !ok || text.Value != "next" {
+		t.Errorf("last block = %+v, want the prompt", last[2])
+	}
+}
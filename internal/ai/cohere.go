@@ -0,0 +1,276 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+const cohereAPIURL = "https://api.cohere.com/v2/chat"
+
+type CohereProvider struct {
+	apiKey     string
+	model      string
+	apiURL     string
+	httpClient *http.Client
+}
+
+func NewCohereProvider(apiKey, model string) (*CohereProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Cohere API key is required")
+	}
+
+	if model == "" {
+		model = "command-r-plus-08-2024" // Default to Command R+
+	}
+
+	return &CohereProvider{
+		apiKey:     apiKey,
+		model:      model,
+		apiURL:     cohereAPIURL,
+		httpClient: &http.Client{Timeout: 120 * time.Second},
+	}, nil
+}
+
+// Close is a no-op; the Cohere client holds no persistent connections
+func (p *CohereProvider) Close() error {
+	return nil
+}
+
+func (p *CohereProvider) GetProviderName() string {
+	return "cohere"
+}
+
+// cohereToolCall is a function call in the v2 Chat API; arguments are a JSON string
+type cohereToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type cohereMessage struct {
+	Role       string           `json:"role"` // "system", "user", "assistant" or "tool"
+	Content    string           `json:"content,omitempty"`
+	ToolPlan   string           `json:"tool_plan,omitempty"` // Assistant reasoning that accompanies tool calls
+	ToolCalls  []cohereToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type cohereTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description,omitempty"`
+		Parameters  interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type cohereRequest struct {
+	Model       string          `json:"model"`
+	Messages    []cohereMessage `json:"messages"`
+	Tools       []cohereTool    `json:"tools,omitempty"`
+	Temperature *float32        `json:"temperature,omitempty"`
+	P           *float32        `json:"p,omitempty"`
+	K           *int32          `json:"k,omitempty"`
+	MaxTokens   *int32          `json:"max_tokens,omitempty"`
+}
+
+type cohereResponse struct {
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		ToolPlan  string           `json:"tool_plan"`
+		ToolCalls []cohereToolCall `json:"tool_calls"`
+	} `json:"message"`
+	Usage struct {
+		Tokens struct {
+			InputTokens  float64 `json:"input_tokens"`
+			OutputTokens float64 `json:"output_tokens"`
+		} `json:"tokens"`
+	} `json:"usage"`
+}
+
+func (p *CohereProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	req := cohereRequest{
+		Model:    p.model,
+		Messages: buildCohereMessages(prompt, conversationHistory),
+	}
+
+	// Apply per-request sampling parameters
+	if genConfig != nil {
+		req.Temperature = genConfig.Temperature
+		req.P = genConfig.TopP
+		req.K = genConfig.TopK
+		req.MaxTokens = genConfig.MaxTokens
+	}
+
+	// Convert MCP tools to Cohere function tools
+	for _, tool := range tools {
+		schema := tool.InputSchema
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object"}
+		}
+		var t cohereTool
+		t.Type = "function"
+		t.Function.Name = tool.Name
+		t.Function.Description = tool.Description
+		t.Function.Parameters = schema
+		req.Tools = append(req.Tools, t)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Cohere request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cohere request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("Cohere API error: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Cohere response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Message != "" {
+			return nil, &HTTPStatusError{
+				StatusCode: httpResp.StatusCode,
+				Message:    fmt.Sprintf("Cohere API error (%d): %s", httpResp.StatusCode, apiErr.Message),
+			}
+		}
+		return nil, &HTTPStatusError{
+			StatusCode: httpResp.StatusCode,
+			Message:    fmt.Sprintf("Cohere API error (%d): %s", httpResp.StatusCode, string(respBody)),
+		}
+	}
+
+	var resp cohereResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Cohere response: %w", err)
+	}
+
+	inputTokens := int(resp.Usage.Tokens.InputTokens)
+	outputTokens := int(resp.Usage.Tokens.OutputTokens)
+	response := &Response{
+		FinishReason: resp.FinishReason,
+		Usage: &Usage{
+			PromptTokens:     inputTokens,
+			CompletionTokens: outputTokens,
+			TotalTokens:      inputTokens + outputTokens,
+		},
+	}
+
+	var text []string
+	for _, block := range resp.Message.Content {
+		if block.Type == "text" {
+			text = append(text, block.Text)
+		}
+	}
+	response.Content = strings.Join(text, "\n")
+	if response.Content == "" {
+		// With tool calls, Cohere explains its next step in tool_plan instead of content
+		response.Content = resp.Message.ToolPlan
+	}
+
+	for _, tc := range resp.Message.ToolCalls {
+		args := make(map[string]interface{})
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+			}
+		}
+		response.ToolCalls = append(response.ToolCalls, ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: args,
+		})
+	}
+
+	return response, nil
+}
+
+// buildCohereMessages maps conversation history and the current prompt onto v2 Chat
+// messages. Assistant tool calls carry their text as the tool plan, and results for
+// those calls are sent back as tool messages
+func buildCohereMessages(prompt string, conversationHistory []Message) []cohereMessage {
+	messages := []cohereMessage{{
+		Role:    "system",
+		Content: "You are a helpful AI assistant that can interact with CloudGenie infrastructure management platform. You have access to various tools to help manage cloud resources. When asked to perform operations, use the available tools to accomplish the task.",
+	}}
+
+	// Tool messages must reference a tool call from the preceding assistant message
+	pendingToolCalls := map[string]bool{}
+
+	for _, msg := range conversationHistory {
+		if len(msg.ToolResults) > 0 {
+			for _, result := range msg.ToolResults {
+				if pendingToolCalls[result.ToolCallID] {
+					messages = append(messages, cohereMessage{Role: "tool", ToolCallID: result.ToolCallID, Content: result.Content})
+					delete(pendingToolCalls, result.ToolCallID)
+				} else {
+					messages = append(messages, cohereMessage{Role: "user", Content: result.Content})
+				}
+			}
+			continue
+		}
+
+		switch msg.Role {
+		case "user":
+			if msg.Content != "" {
+				messages = append(messages, cohereMessage{Role: "user", Content: msg.Content})
+			}
+		case "assistant":
+			pendingToolCalls = map[string]bool{}
+			if len(msg.ToolCalls) == 0 {
+				if msg.Content != "" {
+					messages = append(messages, cohereMessage{Role: "assistant", Content: msg.Content})
+				}
+				continue
+			}
+			assistant := cohereMessage{Role: "assistant", ToolPlan: msg.Content}
+			for _, tc := range msg.ToolCalls {
+				args := tc.Arguments
+				if args == nil {
+					args = make(map[string]interface{})
+				}
+				argsJSON, _ := json.Marshal(args)
+				call := cohereToolCall{ID: tc.ID, Type: "function"}
+				call.Function.Name = tc.Name
+				call.Function.Arguments = string(argsJSON)
+				assistant.ToolCalls = append(assistant.ToolCalls, call)
+				pendingToolCalls[tc.ID] = true
+			}
+			messages = append(messages, assistant)
+		}
+	}
+
+	messages = append(messages, cohereMessage{Role: "user", Content: prompt})
+
+	return messages
+}
@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"errors"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// contextLengthHints are fragments of the messages providers use when a request exceeds
+// the model's context window
+var contextLengthHints = []string{
+	"context_length_exceeded",       // OpenAI, Mistral
+	"maximum context length",        // OpenAI, Ollama
+	"context window",                // Anthropic, Cohere
+	"prompt is too long",            // Anthropic
+	"input is too long",             // Bedrock
+	"too many tokens",               // Cohere, Bedrock
+	"exceeds the maximum number of", // Gemini ("...tokens allowed")
+	"input token count",             // Gemini
+}
+
+// IsContextLengthError reports whether a provider rejected a request because the prompt
+// and history exceed the model's context window. Such requests can succeed with less history
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var openaiAPIErr *openai.APIError
+	if errors.As(err, &openaiAPIErr) {
+		if code, ok := openaiAPIErr.Code.(string); ok && code == "context_length_exceeded" {
+			return true
+		}
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, hint := range contextLengthHints {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
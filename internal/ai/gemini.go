@@ -12,11 +12,12 @@ import (
 )
 
 type GeminiProvider struct {
-	client *genai.Client
-	model  string
+	client         *genai.Client
+	model          string
+	toolCallFormat ToolCallFormat
 }
 
-func NewGeminiProvider(apiKey, model string) (*GeminiProvider, error) {
+func NewGeminiProvider(apiKey, model string, toolCallFormat ToolCallFormat) (*GeminiProvider, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("Google Gemini API key is required")
 	}
@@ -32,8 +33,9 @@ func NewGeminiProvider(apiKey, model string) (*GeminiProvider, error) {
 	}
 
 	return &GeminiProvider{
-		client: client,
-		model:  model,
+		client:         client,
+		model:          model,
+		toolCallFormat: toolCallFormat,
 	}, nil
 }
 
@@ -41,20 +43,67 @@ func (p *GeminiProvider) GetProviderName() string {
 	return "gemini"
 }
 
-func (p *GeminiProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message) (*Response, error) {
+// geminiGenerationConfig returns the provider's sampling defaults with the request's overrides applied
+func geminiGenerationConfig(genConfig *GenerationConfig) genai.GenerationConfig {
+	var config genai.GenerationConfig
+	config.SetTemperature(0.7)
+	config.SetTopP(0.95)
+	config.SetTopK(40)
+	if genConfig != nil {
+		if genConfig.Temperature != nil {
+			config.SetTemperature(*genConfig.Temperature)
+		}
+		if genConfig.TopP != nil {
+			config.SetTopP(*genConfig.TopP)
+		}
+		if genConfig.TopK != nil {
+			config.SetTopK(*genConfig.TopK)
+		}
+		if genConfig.MaxTokens != nil {
+			config.SetMaxOutputTokens(*genConfig.MaxTokens)
+		}
+	}
+	return config
+}
+
+func (p *GeminiProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
 	model := p.client.GenerativeModel(p.model)
 
-	// Configure model
-	model.SetTemperature(0.7)
-	model.SetTopP(0.95)
-	model.SetTopK(40)
-
-	// Build the system instruction with tools information
-	systemPrompt := buildSystemPromptWithTools(tools)
-	
-	// Build the complete prompt with context
-	fullPrompt := systemPrompt + "\n\n"
-	
+	// Configure model, letting the request override the defaults
+	model.GenerationConfig = geminiGenerationConfig(genConfig)
+
+	var resp *genai.GenerateContentResponse
+	var err error
+	if p.toolCallFormat == ToolCallFormatNative {
+		// Declare tools natively so the model answers with structured function calls
+		if len(tools) > 0 {
+			model.Tools = []*genai.Tool{{FunctionDeclarations: geminiFunctionDeclarations(tools)}}
+		}
+		model.SystemInstruction = &genai.Content{
+			Parts: []genai.Part{genai.Text(geminiSystemInstruction)},
+		}
+
+		// Send the history as turns, with tool calls and results as function call/response parts
+		contents := buildGeminiContents(prompt, conversationHistory)
+		session := model.StartChat()
+		session.History = contents[:len(contents)-1]
+		resp, err = session.SendMessage(ctx, contents[len(contents)-1].Parts...)
+	} else {
+		resp, err = model.GenerateContent(ctx, genai.Text(buildGeminiTextPrompt(prompt, tools, conversationHistory, p.toolCallFormat)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Gemini API error: %w", err)
+	}
+
+	return geminiResponse(resp, tools, p.toolCallFormat)
+}
+
+// buildGeminiTextPrompt flattens the tool descriptions, history and prompt into a single
+// prompt for the text tool-call formats
+func buildGeminiTextPrompt(prompt string, tools []*mcp.Tool, conversationHistory []Message, toolCallFormat ToolCallFormat) string {
+	// Describe the tools in the prompt and ask for text tool calls
+	fullPrompt := applyToolCallFormat(buildSystemPromptWithTools(tools, prompt), toolCallFormat) + "\n\n"
+
 	// Add conversation history
 	for _, msg := range conversationHistory {
 		if msg.Role == "user" {
@@ -63,49 +112,141 @@ func (p *GeminiProvider) Chat(ctx context.Context, prompt string, tools []*mcp.T
 			fullPrompt += fmt.Sprintf("Assistant: %s\n", msg.Content)
 		}
 	}
-	
+
 	// Add current prompt with instructions for tool usage
 	fullPrompt += fmt.Sprintf("\nUser: %s\n\n", prompt)
 	fullPrompt += "Assistant: Let me help you with that. "
-	
+
 	// If tools are available, add instruction to use them
 	if len(tools) > 0 {
 		fullPrompt += "I'll use the available tools to accomplish this task. "
 	}
+	return fullPrompt
+}
 
-	// Generate content
-	resp, err := model.GenerateContent(ctx, genai.Text(fullPrompt))
-	if err != nil {
-		return nil, fmt.Errorf("Gemini API error: %w", err)
+// buildGeminiContents maps conversation history and the current prompt onto alternating
+// user/model turns. Assistant tool calls become FunctionCall parts and their results are
+// sent back as FunctionResponse parts in the following user turn
+func buildGeminiContents(prompt string, conversationHistory []Message) []*genai.Content {
+	var contents []*genai.Content
+
+	// Consecutive parts for the same role are merged, since the API expects turns to alternate
+	appendParts := func(role string, parts ...genai.Part) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return
+		}
+		contents = append(contents, &genai.Content{Role: role, Parts: parts})
+	}
+
+	// A function response must answer a call from the preceding model turn, by function name
+	pendingCalls := map[string]string{}
+
+	for _, msg := range conversationHistory {
+		var parts []genai.Part
+
+		if len(msg.ToolResults) > 0 {
+			for _, result := range msg.ToolResults {
+				if name, ok := pendingCalls[result.ToolCallID]; ok {
+					key := "result"
+					if result.IsError {
+						key = "error"
+					}
+					parts = append(parts, genai.FunctionResponse{
+						Name:     name,
+						Response: map[string]interface{}{key: result.Content},
+					})
+					delete(pendingCalls, result.ToolCallID)
+				} else {
+					parts = append(parts, genai.Text(result.Content))
+				}
+			}
+			appendParts("user", parts...)
+			continue
+		}
+
+		if msg.Content != "" {
+			parts = append(parts, genai.Text(msg.Content))
+		}
+
+		switch msg.Role {
+		case "user":
+			appendParts("user", parts...)
+		case "assistant":
+			pendingCalls = map[string]string{}
+			for _, tc := range msg.ToolCalls {
+				args := tc.Arguments
+				if args == nil {
+					args = make(map[string]interface{})
+				}
+				parts = append(parts, genai.FunctionCall{Name: tc.Name, Args: args})
+				pendingCalls[tc.ID] = tc.Name
+			}
+			appendParts("model", parts...)
+		}
 	}
 
-	if len(resp.Candidates) == 0 {
+	appendParts("user", genai.Text(prompt))
+
+	return contents
+}
+
+// geminiResponse converts a Gemini response, reading native function calls and falling
+// back to parsing text tool calls
+func geminiResponse(resp *genai.GenerateContentResponse, tools []*mcp.Tool, toolCallFormat ToolCallFormat) (*Response, error) {
+	if resp == nil || len(resp.Candidates) == 0 {
 		return nil, fmt.Errorf("no response from Gemini")
 	}
 
 	candidate := resp.Candidates[0]
-	
-	// Extract response content
+
+	// Extract response content and native function calls
 	var responseContent string
-	for _, part := range candidate.Content.Parts {
-		if text, ok := part.(genai.Text); ok {
-			responseContent += string(text)
+	var toolCalls []ToolCall
+	if candidate.Content != nil {
+		for i, part := range candidate.Content.Parts {
+			switch part := part.(type) {
+			case genai.Text:
+				responseContent += string(part)
+			case genai.FunctionCall:
+				args := part.Args
+				if args == nil {
+					args = make(map[string]interface{})
+				}
+				toolCalls = append(toolCalls, ToolCall{
+					ID:        fmt.Sprintf("gemini_call_%d", i),
+					Name:      part.Name,
+					Arguments: args,
+				})
+			}
 		}
 	}
 
 	response := &Response{
 		Content:      responseContent,
 		FinishReason: fmt.Sprintf("%v", candidate.FinishReason),
-		Usage: &Usage{
-			PromptTokens:     0,
-			CompletionTokens: 0,
-			TotalTokens:      0,
-		},
+		Usage:        &Usage{},
+	}
+	if resp.UsageMetadata != nil {
+		response.Usage.PromptTokens = int(resp.UsageMetadata.PromptTokenCount)
+		response.Usage.CompletionTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+		response.Usage.TotalTokens = int(resp.UsageMetadata.TotalTokenCount)
 	}
 
-	// Parse tool calls from the response
-	// Look for tool call patterns in the format: TOOL_CALL: tool_name({"arg": "value"})
-	toolCalls := extractToolCalls(responseContent, tools)
+	// Parse text tool calls for the text formats, and as a fallback for models
+	// that answer in text despite native function declarations
+	if len(toolCalls) == 0 {
+		switch toolCallFormat {
+		case ToolCallFormatText, ToolCallFormatNative, "":
+			// Look for tool call patterns in the format: TOOL_CALL: tool_name({"arg": "value"})
+			toolCalls = extractToolCalls(responseContent, tools)
+		default:
+			toolCalls = extractToolCallsForFormat(toolCallFormat, responseContent, tools)
+		}
+	}
 	if len(toolCalls) > 0 {
 		response.ToolCalls = toolCalls
 	}
@@ -113,8 +254,103 @@ func (p *GeminiProvider) Chat(ctx context.Context, prompt string, tools []*mcp.T
 	return response, nil
 }
 
-// buildSystemPromptWithTools creates a system prompt that includes tool information
-func buildSystemPromptWithTools(tools []*mcp.Tool) string {
+// geminiSystemInstruction is the system prompt used with native function calling
+const geminiSystemInstruction = "You are a helpful AI assistant that can interact with CloudGenie infrastructure management platform. You have access to various tools to help manage cloud resources. When asked to perform operations, call the available functions to accomplish the task, then explain what you did."
+
+// geminiFunctionDeclarations converts MCP tools into Gemini function declarations
+func geminiFunctionDeclarations(tools []*mcp.Tool) []*genai.FunctionDeclaration {
+	declarations := make([]*genai.FunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		declaration := &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+		}
+		// Tools without parameters are declared without a schema; Gemini rejects empty objects
+		if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
+			if props, ok := schema["properties"].(map[string]interface{}); ok && len(props) > 0 {
+				declaration.Parameters = geminiSchema(schema)
+			}
+		}
+		declarations = append(declarations, declaration)
+	}
+	return declarations
+}
+
+// geminiSchema converts a JSON Schema object into the OpenAPI subset Gemini accepts
+func geminiSchema(schema map[string]interface{}) *genai.Schema {
+	result := &genai.Schema{Type: genai.TypeString}
+	if d, ok := schema["description"].(string); ok {
+		result.Description = d
+	}
+
+	// "type" may be a list such as ["string", "null"]
+	var schemaType string
+	switch t := schema["type"].(type) {
+	case string:
+		schemaType = t
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				if name == "null" {
+					result.Nullable = true
+				} else if schemaType == "" {
+					schemaType = name
+				}
+			}
+		}
+	}
+	if schemaType == "" {
+		if _, ok := schema["properties"]; ok {
+			schemaType = "object"
+		}
+	}
+
+	switch schemaType {
+	case "number":
+		result.Type = genai.TypeNumber
+	case "integer":
+		result.Type = genai.TypeInteger
+	case "boolean":
+		result.Type = genai.TypeBoolean
+	case "array":
+		result.Type = genai.TypeArray
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			result.Items = geminiSchema(items)
+		} else {
+			result.Items = &genai.Schema{Type: genai.TypeString}
+		}
+	case "object":
+		result.Type = genai.TypeObject
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			result.Properties = make(map[string]*genai.Schema, len(props))
+			for name, prop := range props {
+				if propSchema, ok := prop.(map[string]interface{}); ok {
+					result.Properties[name] = geminiSchema(propSchema)
+				}
+			}
+		}
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					result.Required = append(result.Required, name)
+				}
+			}
+		}
+	default:
+		if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+			result.Format = "enum"
+			for _, v := range enum {
+				result.Enum = append(result.Enum, fmt.Sprint(v))
+			}
+		}
+	}
+
+	return result
+}
+
+// buildSystemPromptWithTools creates a system prompt that includes tool information,
+// abbreviating the tools least relevant to query when the full list exceeds the budget
+func buildSystemPromptWithTools(tools []*mcp.Tool, query string) string {
 	prompt := `You are a helpful AI assistant that can interact with CloudGenie infrastructure management platform.
 
 You have access to the following tools to help manage cloud resources. When you need to perform an action, you should call the appropriate tool by responding in this EXACT format:
@@ -128,45 +364,7 @@ TOOL_CALL: cloudgenie_create_resource({"name": "my-db", "type": "database", "blu
 Available tools:
 `
 
-	for _, tool := range tools {
-		prompt += fmt.Sprintf("\n%s: %s\n", tool.Name, tool.Description)
-		
-		// Add parameter information
-		if tool.InputSchema != nil {
-			// Type assert InputSchema to map[string]interface{}
-			if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
-				if props, ok := schema["properties"].(map[string]interface{}); ok {
-					prompt += "  Parameters:\n"
-					for paramName, paramInfo := range props {
-						if paramMap, ok := paramInfo.(map[string]interface{}); ok {
-							paramType := "string"
-							if t, ok := paramMap["type"].(string); ok {
-								paramType = t
-							}
-							paramDesc := ""
-							if d, ok := paramMap["description"].(string); ok {
-								paramDesc = d
-							}
-							prompt += fmt.Sprintf("    - %s (%s): %s\n", paramName, paramType, paramDesc)
-						}
-					}
-				}
-				
-				// Add required fields
-				if required, ok := schema["required"].([]interface{}); ok && len(required) > 0 {
-					reqFields := []string{}
-					for _, r := range required {
-						if rs, ok := r.(string); ok {
-							reqFields = append(reqFields, rs)
-						}
-					}
-					if len(reqFields) > 0 {
-						prompt += fmt.Sprintf("  Required: %s\n", strings.Join(reqFields, ", "))
-					}
-				}
-			}
-		}
-	}
+	prompt += renderToolsWithinBudget(tools, query, renderToolText)
 
 	prompt += `
 IMPORTANT RULES:
@@ -189,39 +387,96 @@ I'm fetching all available infrastructure blueprints for you.
 	return prompt
 }
 
+// renderToolText describes a tool for the text tool-call prompt at the given detail level
+func renderToolText(tool *mcp.Tool, detail toolDetail) string {
+	switch detail {
+	case toolDetailName:
+		return fmt.Sprintf("\n%s\n", tool.Name)
+	case toolDetailBrief:
+		text := fmt.Sprintf("\n%s: %s\n", tool.Name, firstSentence(tool.Description))
+		if params, required := toolParamNames(tool); len(params) > 0 {
+			text += fmt.Sprintf("  Parameters: %s\n", strings.Join(params, ", "))
+			if len(required) > 0 {
+				text += fmt.Sprintf("  Required: %s\n", strings.Join(required, ", "))
+			}
+		}
+		return text
+	}
+
+	text := fmt.Sprintf("\n%s: %s\n", tool.Name, tool.Description)
+
+	// Add parameter information
+	if tool.InputSchema != nil {
+		// Type assert InputSchema to map[string]interface{}
+		if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
+			if props, ok := schema["properties"].(map[string]interface{}); ok {
+				text += "  Parameters:\n"
+				for paramName, paramInfo := range props {
+					if paramMap, ok := paramInfo.(map[string]interface{}); ok {
+						paramType := "string"
+						if t, ok := paramMap["type"].(string); ok {
+							paramType = t
+						}
+						paramDesc := ""
+						if d, ok := paramMap["description"].(string); ok {
+							paramDesc = d
+						}
+						text += fmt.Sprintf("    - %s (%s): %s\n", paramName, paramType, paramDesc)
+					}
+				}
+			}
+
+			// Add required fields
+			if required, ok := schema["required"].([]interface{}); ok && len(required) > 0 {
+				reqFields := []string{}
+				for _, r := range required {
+					if rs, ok := r.(string); ok {
+						reqFields = append(reqFields, rs)
+					}
+				}
+				if len(reqFields) > 0 {
+					text += fmt.Sprintf("  Required: %s\n", strings.Join(reqFields, ", "))
+				}
+			}
+		}
+	}
+
+	return text
+}
+
 // extractToolCalls parses the response to find tool call requests
 func extractToolCalls(content string, tools []*mcp.Tool) []ToolCall {
 	var toolCalls []ToolCall
-	
+
 	// Create a map of valid tool names for quick lookup
 	validTools := make(map[string]bool)
 	for _, tool := range tools {
 		validTools[tool.Name] = true
 	}
-	
+
 	// Split by lines and look for TOOL_CALL patterns
 	lines := strings.Split(content, "\n")
 	for i, line := range lines {
 		line = strings.TrimSpace(line)
-		
+
 		// Look for TOOL_CALL: pattern
 		if strings.HasPrefix(line, "TOOL_CALL:") {
 			// Extract the tool call: tool_name(json_args)
 			callPart := strings.TrimSpace(strings.TrimPrefix(line, "TOOL_CALL:"))
-			
+
 			// Find the opening parenthesis
 			parenIdx := strings.Index(callPart, "(")
 			if parenIdx == -1 {
 				continue
 			}
-			
+
 			toolName := strings.TrimSpace(callPart[:parenIdx])
-			
+
 			// Validate tool name
 			if !validTools[toolName] {
 				continue
 			}
-			
+
 			// Extract JSON arguments
 			argsStr := callPart[parenIdx+1:]
 			// Find the closing parenthesis
@@ -229,14 +484,14 @@ func extractToolCalls(content string, tools []*mcp.Tool) []ToolCall {
 			if closeParenIdx != -1 {
 				argsStr = argsStr[:closeParenIdx]
 			}
-			
+
 			// Parse JSON arguments
 			var args map[string]interface{}
 			if err := json.Unmarshal([]byte(argsStr), &args); err != nil {
 				// If parsing fails, try with empty args
 				args = make(map[string]interface{})
 			}
-			
+
 			// Create tool call with unique ID
 			toolCalls = append(toolCalls, ToolCall{
 				ID:        fmt.Sprintf("gemini_call_%d", i),
@@ -245,7 +500,7 @@ func extractToolCalls(content string, tools []*mcp.Tool) []ToolCall {
 			})
 		}
 	}
-	
+
 	return toolCalls
 }
 
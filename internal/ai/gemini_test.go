@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestGeminiResponseReadsFunctionCalls(t *testing.T) {
+	tools := []*mcp.Tool{{Name: "cloudgenie_create_resource"}}
+	resp := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content: &genai.Content{
+				Role: "model",
+				Parts: []genai.Part{
+					genai.Text("Creating the database."),
+					genai.FunctionCall{
+						Name: "cloudgenie_create_resource",
+						Args: map[string]any{"name": "orders-db", "replicas": float64(2)},
+					},
+					genai.FunctionCall{Name: "cloudgenie_create_resource"},
+				},
+			},
+		}},
+		UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: 5, TotalTokenCount: 15},
+	}
+
+	got, err := geminiResponse(resp, tools, ToolCallFormatNative)
+	if err != nil {
+		t.Fatalf("geminiResponse: %v", err)
+	}
+	if got.Content != "Creating the database." {
+		t.Errorf("Content = %q", got.Content)
+	}
+	want := []ToolCall{
+		{ID: "gemini_call_1", Name: "cloudgenie_create_resource", Arguments: map[string]interface{}{"name": "orders-db", "replicas": float64(2)}},
+		{ID: "gemini_call_2", Name: "cloudgenie_create_resource", Arguments: map[string]interface{}{}},
+	}
+	if !reflect.DeepEqual(got.ToolCalls, want) {
+		t.Errorf("ToolCalls = %+v, want %+v", got.ToolCalls, want)
+	}
+	if got.Usage.TotalTokens != 15 {
+		t.Errorf("Usage.TotalTokens = %d, want 15", got.Usage.TotalTokens)
+	}
+}
+
+func TestGeminiResponseFallsBackToTextToolCalls(t *testing.T) {
+	tools := []*mcp.Tool{{Name: "cloudgenie_get_blueprints"}}
+	resp := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content: &genai.Content{Parts: []genai.Part{genai.Text("TOOL_CALL: cloudgenie_get_blueprints({})")}},
+		}},
+	}
+
+	got, err := geminiResponse(resp, tools, ToolCallFormatNative)
+	if err != nil {
+		t.Fatalf("geminiResponse: %v", err)
+	}
+	if len(got.ToolCalls) != 1 || got.ToolCalls[0].Name != "cloudgenie_get_blueprints" {
+		t.Errorf("ToolCalls = %+v, want one cloudgenie_get_blueprints call", got.ToolCalls)
+	}
+}
+
+func TestGeminiResponseWithoutCandidates(t *testing.T) {
+	if _, err := geminiResponse(&genai.GenerateContentResponse{}, nil, ToolCallFormatNative); err == nil {
+		t.Error("expected an error for a response without candidates")
+	}
+}
+
+func TestBuildGeminiContents(t *testing.T) {
+	history := []Message{
+		{Role: "user", Content: "create a db"},
+		{Role: "assistant", Content: "On it.", ToolCalls: []ToolCall{
+			{ID: "gemini_call_0", Name: "create_resource", Arguments: map[string]interface{}{"name": "db"}},
+			{ID: "gemini_call_1", Name: "get_blueprints"},
+		}},
+		{Role: "assistant", ToolResults: []ToolResult{
+			{ToolCallID: "gemini_call_0", Content: "created"},
+			{ToolCallID: "gemini_call_1", Content: "no access", IsError: true},
+			{ToolCallID: "confirm-token", Content: "confirmed call ran"},
+		}},
+	}
+
+	got := buildGeminiContents("Tool execution results: ...", history)
+
+	want := []*genai.Content{
+		{Role: "user", Parts: []genai.Part{genai.Text("create a db")}},
+		{Role: "model", Parts: []genai.Part{
+			genai.Text("On it."),
+			genai.FunctionCall{Name: "create_resource", Args: map[string]any{"name": "db"}},
+			genai.FunctionCall{Name: "get_blueprints", Args: map[string]any{}},
+		}},
+		{Role: "user", Parts: []genai.Part{
+			genai.FunctionResponse{Name: "create_resource", Response: map[string]any{"result": "created"}},
+			genai.FunctionResponse{Name: "get_blueprints", Response: map[string]any{"error": "no access"}},
+			genai.Text("confirmed call ran"),
+			genai.Text("Tool execution results: ..."),
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildGeminiContents() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestGeminiGenerationConfig(t *testing.T) {
+	temperature, topP, topK, maxTokens := float32(0), float32(0.5), int32(8), int32(256)
+	tests := []struct {
+		name      string
+		genConfig *GenerationConfig
+		want      genai.GenerationConfig
+	}{
+		{
+			name: "defaults",
+			want: genai.GenerationConfig{Temperature: ptr(float32(0.7)), TopP: ptr(float32(0.95)), TopK: ptr(int32(40))},
+		},
+		{
+			name:      "overrides",
+			genConfig: &GenerationConfig{Temperature: &temperature, TopP: &topP, TopK: &topK, MaxTokens: &maxTokens},
+			want:      genai.GenerationConfig{Temperature: &temperature, TopP: &topP, TopK: &topK, MaxOutputTokens: &maxTokens},
+		},
+		{
+			name:      "partial override keeps other defaults",
+			genConfig: &GenerationConfig{TopK: &topK},
+			want:      genai.GenerationConfig{Temperature: ptr(float32(0.7)), TopP: ptr(float32(0.95)), TopK: &topK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := geminiGenerationConfig(tt.genConfig); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("geminiGenerationConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
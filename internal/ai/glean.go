@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
 	glean "github.com/gleanwork/api-client-go"
@@ -12,11 +13,12 @@ import (
 )
 
 type GleanProvider struct {
-	client   *glean.Glean
-	instance string
+	client         *glean.Glean
+	instance       string
+	toolCallFormat ToolCallFormat
 }
 
-func NewGleanProvider(apiKey, instance, model string) (*GleanProvider, error) {
+func NewGleanProvider(apiKey, instance, model string, toolCallFormat ToolCallFormat) (*GleanProvider, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("Glean API key is required")
 	}
@@ -25,6 +27,10 @@ func NewGleanProvider(apiKey, instance, model string) (*GleanProvider, error) {
 		return nil, fmt.Errorf("Glean instance is required (e.g., 'your-company')")
 	}
 
+	if toolCallFormat == ToolCallFormatNative {
+		return nil, fmt.Errorf("Glean does not support native tool calls; use tool_call_text, json_block or xml_tag")
+	}
+
 	// Create Glean client using official SDK
 	client := glean.New(
 		glean.WithSecurity(apiKey),
@@ -32,22 +38,29 @@ func NewGleanProvider(apiKey, instance, model string) (*GleanProvider, error) {
 	)
 
 	return &GleanProvider{
-		client:   client,
-		instance: instance,
+		client:         client,
+		instance:       instance,
+		toolCallFormat: toolCallFormat,
 	}, nil
 }
 
+// Close is a no-op; the Glean client holds no persistent connections
+func (p *GleanProvider) Close() error {
+	return nil
+}
+
 func (p *GleanProvider) GetProviderName() string {
 	return "glean"
 }
 
-func (p *GleanProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message) (*Response, error) {
+func (p *GleanProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	// Glean's chat API doesn't expose sampling parameters, so genConfig is ignored
 	// Build system prompt with tools information
-	systemPrompt := buildSystemPromptWithToolsGlean(tools)
-	
+	systemPrompt := applyToolCallFormat(buildSystemPromptWithToolsGlean(tools, prompt), p.toolCallFormat)
+
 	// Build messages using Glean SDK types
 	messages := []components.ChatMessage{}
-	
+
 	// Add system prompt as first user message
 	if systemPrompt != "" {
 		messages = append(messages, components.ChatMessage{
@@ -57,11 +70,11 @@ func (p *GleanProvider) Chat(ctx context.Context, prompt string, tools []*mcp.To
 		})
 		messages = append(messages, components.ChatMessage{
 			Fragments: []components.ChatMessageFragment{
-				{Text: glean.String("Understood. I will use the TOOL_CALL format when I need to use tools.")},
+				{Text: glean.String("Understood. I will use the tool call format shown above when I need to use tools.")},
 			},
 		})
 	}
-	
+
 	// Add conversation history
 	for _, msg := range conversationHistory {
 		if msg.Role == "user" {
@@ -77,9 +90,9 @@ func (p *GleanProvider) Chat(ctx context.Context, prompt string, tools []*mcp.To
 				content += "\n\nTool Results:\n"
 				for _, tr := range msg.ToolResults {
 					if tr.IsError {
-						content += fmt.Sprintf(" Error: %s\n", tr.Content)
+						content += fmt.Sprintf("[ERROR] %s\n", tr.Content)
 					} else {
-						content += fmt.Sprintf("✓ %s\n", tr.Content)
+						content += fmt.Sprintf("[OK] %s\n", tr.Content)
 					}
 				}
 			}
@@ -90,7 +103,7 @@ func (p *GleanProvider) Chat(ctx context.Context, prompt string, tools []*mcp.To
 			})
 		}
 	}
-	
+
 	// Add current user prompt
 	messages = append(messages, components.ChatMessage{
 		Fragments: []components.ChatMessageFragment{
@@ -120,8 +133,13 @@ func (p *GleanProvider) Chat(ctx context.Context, prompt string, tools []*mcp.To
 		}
 	}
 
-	// Extract tool calls from content using the same pattern as Gemini
-	toolCalls := extractToolCallsGlean(content, tools)
+	// Extract tool calls from content in the configured format
+	var toolCalls []ToolCall
+	if p.toolCallFormat == ToolCallFormatText || p.toolCallFormat == "" {
+		toolCalls = extractToolCallsGlean(content, tools)
+	} else {
+		toolCalls = extractToolCallsForFormat(p.toolCallFormat, content, tools)
+	}
 
 	return &Response{
 		Content:      content,
@@ -131,8 +149,9 @@ func (p *GleanProvider) Chat(ctx context.Context, prompt string, tools []*mcp.To
 	}, nil
 }
 
-// buildSystemPromptWithToolsGlean creates a system prompt that includes tool information
-func buildSystemPromptWithToolsGlean(tools []*mcp.Tool) string {
+// buildSystemPromptWithToolsGlean creates a system prompt that includes tool information,
+// abbreviating the tools least relevant to query when the full list exceeds the budget
+func buildSystemPromptWithToolsGlean(tools []*mcp.Tool, query string) string {
 	if len(tools) == 0 {
 		return "You are a helpful AI assistant for infrastructure and DevOps tasks."
 	}
@@ -149,10 +168,10 @@ Your Capabilities:
 CRITICAL INSTRUCTIONS - When to Use Tools:
 
 ALWAYS call tools for these requests (call tool ONLY ONCE):
-✓ "Show blueprints" / "List blueprints" / "Get blueprints" → Call get_blueprints ONCE
-✓ "Show resources" / "List resources" / "Get resources" → Call get_resources ONCE
-✓ "Create/Deploy [specific resource]" (e.g., "Create a web server") → Call create_resource ONCE
-✓ "Get details about [resource_name]" → Call get_resource_by_name ONCE
+- "Show blueprints" / "List blueprints" / "Get blueprints" -> Call get_blueprints ONCE
+- "Show resources" / "List resources" / "Get resources" -> Call get_resources ONCE
+- "Create/Deploy [specific resource]" (e.g., "Create a web server") -> Call create_resource ONCE
+- "Get details about [resource_name]" -> Call get_resource_by_name ONCE
 
 Capability Questions - CRITICAL RESPONSE FORMAT:
 When user asks "Can you deploy [X]?" or "Do you support [X]?":
@@ -173,10 +192,10 @@ When user asks "Can you deploy [X]?" or "Do you support [X]?":
    "Yes, I can deploy a [X] using the [blueprint-name] blueprint. Would you like me to create one for you?"
 
 NEVER call tools for these requests:
-✗ "How can you help?" / "What can you do?" → Answer with your capabilities directly
-✗ "What is Kubernetes?" / General knowledge questions → Answer from your knowledge
-✗ Conversational questions or greetings → Respond naturally
-✗ NEVER call the same tool multiple times in a single response
+- "How can you help?" / "What can you do?" -> Answer with your capabilities directly
+- "What is Kubernetes?" / General knowledge questions -> Answer from your knowledge
+- Conversational questions or greetings -> Respond naturally
+- NEVER call the same tool multiple times in a single response
 
 Tool Calling Format:
 When you need to use a tool, use this exact format:
@@ -191,69 +210,14 @@ Available Tools:
 
 `
 
-	for _, tool := range tools {
-		prompt += fmt.Sprintf("🔧 %s\n", tool.Name)
-		prompt += fmt.Sprintf("   Description: %s\n", tool.Description)
-		
-		if tool.InputSchema != nil {
-			if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
-				if properties, ok := schema["properties"].(map[string]interface{}); ok {
-					if len(properties) > 0 {
-						prompt += "   Parameters:\n"
-						
-						// Get required fields
-						requiredFields := []string{}
-						if required, ok := schema["required"].([]interface{}); ok {
-							for _, req := range required {
-								if reqStr, ok := req.(string); ok {
-									requiredFields = append(requiredFields, reqStr)
-								}
-							}
-						}
-						
-						for paramName, paramInfo := range properties {
-							if paramMap, ok := paramInfo.(map[string]interface{}); ok {
-								paramType := "any"
-								if t, ok := paramMap["type"].(string); ok {
-									paramType = t
-								}
-								paramDesc := ""
-								if d, ok := paramMap["description"].(string); ok {
-									paramDesc = d
-								}
-								
-								// Check if required
-								isRequired := false
-								for _, req := range requiredFields {
-									if req == paramName {
-										isRequired = true
-										break
-									}
-								}
-								
-								requiredMark := ""
-								if isRequired {
-									requiredMark = " [REQUIRED]"
-								}
-								
-								prompt += fmt.Sprintf("      • %s (%s)%s: %s\n", paramName, paramType, requiredMark, paramDesc)
-							}
-						}
-					} else {
-						prompt += "   Parameters: None required\n"
-					}
-				}
-			}
-		}
-		prompt += "\n"
-	}
+	prompt += renderToolsWithinBudget(tools, query, renderToolGlean)
 
 	prompt += `
 Important Guidelines:
 1. Be conversational and helpful in your responses
 2. Call each tool ONLY ONCE per response - NEVER call the same tool multiple times
 3. For capability questions ("Can you...?"), START your answer with a clear YES or NO
-4. For capability questions, check blueprints and match the requested service name exactly
+4. For capability questions, check blueprints and match the requested service name ignoring case, hyphens and underscores (e.g. "Postgres-DB" = "postgres_db"), then by common synonyms (db -> postgres/mysql)
 5. When using tools, use the TOOL_CALL format exactly as shown above
 6. Provide all REQUIRED parameters when calling tools
 7. After receiving tool results, analyze them and provide a clear, helpful response
@@ -304,27 +268,99 @@ Now, help the user with their request!
 	return prompt
 }
 
+// renderToolGlean describes a tool for the Glean prompt at the given detail level
+func renderToolGlean(tool *mcp.Tool, detail toolDetail) string {
+	switch detail {
+	case toolDetailName:
+		return fmt.Sprintf("Tool: %s\n\n", tool.Name)
+	case toolDetailBrief:
+		text := fmt.Sprintf("Tool: %s\n   Description: %s\n", tool.Name, firstSentence(tool.Description))
+		if params, required := toolParamNames(tool); len(params) > 0 {
+			text += fmt.Sprintf("   Parameters: %s\n", strings.Join(params, ", "))
+			if len(required) > 0 {
+				text += fmt.Sprintf("   Required: %s\n", strings.Join(required, ", "))
+			}
+		}
+		return text + "\n"
+	}
+
+	text := fmt.Sprintf("Tool: %s\n", tool.Name)
+	text += fmt.Sprintf("   Description: %s\n", tool.Description)
+
+	if tool.InputSchema != nil {
+		if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
+			if properties, ok := schema["properties"].(map[string]interface{}); ok {
+				if len(properties) > 0 {
+					text += "   Parameters:\n"
+
+					// Get required fields
+					requiredFields := []string{}
+					if required, ok := schema["required"].([]interface{}); ok {
+						for _, req := range required {
+							if reqStr, ok := req.(string); ok {
+								requiredFields = append(requiredFields, reqStr)
+							}
+						}
+					}
+
+					for paramName, paramInfo := range properties {
+						if paramMap, ok := paramInfo.(map[string]interface{}); ok {
+							paramType := "any"
+							if t, ok := paramMap["type"].(string); ok {
+								paramType = t
+							}
+							paramDesc := ""
+							if d, ok := paramMap["description"].(string); ok {
+								paramDesc = d
+							}
+
+							// Check if required
+							isRequired := false
+							for _, req := range requiredFields {
+								if req == paramName {
+									isRequired = true
+									break
+								}
+							}
+
+							requiredMark := ""
+							if isRequired {
+								requiredMark = " [REQUIRED]"
+							}
+
+							text += fmt.Sprintf("      - %s (%s)%s: %s\n", paramName, paramType, requiredMark, paramDesc)
+						}
+					}
+				} else {
+					text += "   Parameters: None required\n"
+				}
+			}
+		}
+	}
+	return text + "\n"
+}
+
 // extractToolCallsGlean extracts tool calls from the model's response
 func extractToolCallsGlean(content string, tools []*mcp.Tool) []ToolCall {
 	toolCalls := []ToolCall{}
-	
+
 	// Pattern 1: TOOL_CALL: tool_name({"param": "value"})
 	pattern1 := regexp.MustCompile(`TOOL_CALL:\s*([a-zA-Z0-9_-]+)\s*\((.*?)\)`)
 	matches1 := pattern1.FindAllStringSubmatch(content, -1)
-	
+
 	// Pattern 2: TOOL_CALL: tool_name (without parentheses)
 	pattern2 := regexp.MustCompile(`TOOL_CALL:\s*([a-zA-Z0-9_-]+)\s*(?:\n|$)`)
 	matches2 := pattern2.FindAllStringSubmatch(content, -1)
-	
+
 	// Process pattern 1 matches (with arguments)
 	for i, match := range matches1 {
 		if len(match) < 3 {
 			continue
 		}
-		
+
 		toolName := match[1]
 		argsJSON := match[2]
-		
+
 		// Validate tool exists
 		toolExists := false
 		for _, tool := range tools {
@@ -333,34 +369,34 @@ func extractToolCallsGlean(content string, tools []*mcp.Tool) []ToolCall {
 				break
 			}
 		}
-		
+
 		if !toolExists {
 			continue
 		}
-		
+
 		// Parse arguments
 		var args map[string]interface{}
 		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
 			// If parsing fails, try with empty args
 			args = make(map[string]interface{})
 		}
-		
+
 		toolCalls = append(toolCalls, ToolCall{
 			ID:        fmt.Sprintf("call_%d", i+1),
 			Name:      toolName,
 			Arguments: args,
 		})
 	}
-	
+
 	// Process pattern 2 matches (without arguments) - only if pattern 1 didn't match
 	if len(toolCalls) == 0 {
 		for i, match := range matches2 {
 			if len(match) < 2 {
 				continue
 			}
-			
+
 			toolName := match[1]
-			
+
 			// Validate tool exists
 			toolExists := false
 			for _, tool := range tools {
@@ -369,14 +405,14 @@ func extractToolCallsGlean(content string, tools []*mcp.Tool) []ToolCall {
 					break
 				}
 			}
-			
+
 			if !toolExists {
 				continue
 			}
-			
+
 			// Use empty args for tools without parameters
 			args := make(map[string]interface{})
-			
+
 			toolCalls = append(toolCalls, ToolCall{
 				ID:        fmt.Sprintf("call_%d", i+1),
 				Name:      toolName,
@@ -384,6 +420,6 @@ func extractToolCallsGlean(content string, tools []*mcp.Tool) []ToolCall {
 			})
 		}
 	}
-	
+
 	return toolCalls
 }
@@ -0,0 +1,259 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+const mistralAPIURL = "https://api.mistral.ai/v1/chat/completions"
+
+type MistralProvider struct {
+	apiKey     string
+	model      string
+	apiURL     string
+	httpClient *http.Client
+}
+
+func NewMistralProvider(apiKey, model string) (*MistralProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Mistral API key is required")
+	}
+
+	if model == "" {
+		model = "mistral-large-latest" // Default to Mistral Large
+	}
+
+	return &MistralProvider{
+		apiKey:     apiKey,
+		model:      model,
+		apiURL:     mistralAPIURL,
+		httpClient: &http.Client{Timeout: 120 * time.Second},
+	}, nil
+}
+
+// Close is a no-op; the Mistral client holds no persistent connections
+func (p *MistralProvider) Close() error {
+	return nil
+}
+
+func (p *MistralProvider) GetProviderName() string {
+	return "mistral"
+}
+
+// mistralToolCall is a function call in the Chat Completions API; arguments are a JSON string
+type mistralToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type mistralMessage struct {
+	Role       string            `json:"role"` // "system", "user", "assistant" or "tool"
+	Content    string            `json:"content"`
+	ToolCalls  []mistralToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string            `json:"tool_call_id,omitempty"`
+	Name       string            `json:"name,omitempty"` // Tool name, on tool messages
+}
+
+type mistralTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description,omitempty"`
+		Parameters  interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type mistralRequest struct {
+	Model       string           `json:"model"`
+	Messages    []mistralMessage `json:"messages"`
+	Tools       []mistralTool    `json:"tools,omitempty"`
+	ToolChoice  string           `json:"tool_choice,omitempty"`
+	Temperature *float32         `json:"temperature,omitempty"`
+	TopP        *float32         `json:"top_p,omitempty"`
+	MaxTokens   *int32           `json:"max_tokens,omitempty"`
+}
+
+type mistralResponse struct {
+	Choices []struct {
+		Message      mistralMessage `json:"message"`
+		FinishReason string         `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func (p *MistralProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	req := mistralRequest{
+		Model:    p.model,
+		Messages: buildMistralMessages(prompt, conversationHistory),
+	}
+
+	// Apply per-request sampling parameters (Mistral has no top_k)
+	if genConfig != nil {
+		req.Temperature = genConfig.Temperature
+		req.TopP = genConfig.TopP
+		req.MaxTokens = genConfig.MaxTokens
+	}
+
+	// Convert MCP tools to Mistral function tools
+	for _, tool := range tools {
+		schema := tool.InputSchema
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object"}
+		}
+		var t mistralTool
+		t.Type = "function"
+		t.Function.Name = tool.Name
+		t.Function.Description = tool.Description
+		t.Function.Parameters = schema
+		req.Tools = append(req.Tools, t)
+	}
+	if len(req.Tools) > 0 {
+		req.ToolChoice = "auto"
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Mistral request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Mistral request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("Mistral API error: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Mistral response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Message != "" {
+			return nil, &HTTPStatusError{
+				StatusCode: httpResp.StatusCode,
+				Message:    fmt.Sprintf("Mistral API error (%d): %s", httpResp.StatusCode, apiErr.Message),
+			}
+		}
+		return nil, &HTTPStatusError{
+			StatusCode: httpResp.StatusCode,
+			Message:    fmt.Sprintf("Mistral API error (%d): %s", httpResp.StatusCode, string(respBody)),
+		}
+	}
+
+	var resp mistralResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Mistral response: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from Mistral")
+	}
+
+	choice := resp.Choices[0]
+	response := &Response{
+		Content:      choice.Message.Content,
+		FinishReason: choice.FinishReason,
+		Usage: &Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}
+
+	for _, tc := range choice.Message.ToolCalls {
+		args := make(map[string]interface{})
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+			}
+		}
+		response.ToolCalls = append(response.ToolCalls, ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: args,
+		})
+	}
+
+	return response, nil
+}
+
+// buildMistralMessages maps conversation history and the current prompt onto Chat
+// Completions messages, with results for the assistant's tool calls sent back as tool messages
+func buildMistralMessages(prompt string, conversationHistory []Message) []mistralMessage {
+	messages := []mistralMessage{{
+		Role:    "system",
+		Content: "You are a helpful AI assistant that can interact with CloudGenie infrastructure management platform. You have access to various tools to help manage cloud resources. When asked to perform operations, use the available tools to accomplish the task.",
+	}}
+
+	// Tool messages must reference a tool call from the preceding assistant message
+	pendingToolCalls := map[string]string{} // call ID -> tool name
+
+	for _, msg := range conversationHistory {
+		if len(msg.ToolResults) > 0 {
+			for _, result := range msg.ToolResults {
+				if name, ok := pendingToolCalls[result.ToolCallID]; ok {
+					messages = append(messages, mistralMessage{Role: "tool", ToolCallID: result.ToolCallID, Name: name, Content: result.Content})
+					delete(pendingToolCalls, result.ToolCallID)
+				} else {
+					messages = append(messages, mistralMessage{Role: "user", Content: result.Content})
+				}
+			}
+			continue
+		}
+
+		switch msg.Role {
+		case "user":
+			if msg.Content != "" {
+				messages = append(messages, mistralMessage{Role: "user", Content: msg.Content})
+			}
+		case "assistant":
+			pendingToolCalls = map[string]string{}
+			if msg.Content == "" && len(msg.ToolCalls) == 0 {
+				continue
+			}
+			assistant := mistralMessage{Role: "assistant", Content: msg.Content}
+			for _, tc := range msg.ToolCalls {
+				args := tc.Arguments
+				if args == nil {
+					args = make(map[string]interface{})
+				}
+				argsJSON, _ := json.Marshal(args)
+				call := mistralToolCall{ID: tc.ID, Type: "function"}
+				call.Function.Name = tc.Name
+				call.Function.Arguments = string(argsJSON)
+				assistant.ToolCalls = append(assistant.ToolCalls, call)
+				pendingToolCalls[tc.ID] = tc.Name
+			}
+			messages = append(messages, assistant)
+		}
+	}
+
+	messages = append(messages, mistralMessage{Role: "user", Content: prompt})
+
+	return messages
+}
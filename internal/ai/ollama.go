@@ -0,0 +1,170 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+type OllamaProvider struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+func NewOllamaProvider(baseURL, model string) (*OllamaProvider, error) {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+
+	if model == "" {
+		model = "llama3.1" // Default to Llama 3.1
+	}
+
+	return &OllamaProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		// Local models can be slow, especially on the first call while the model loads
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Close is a no-op; the Ollama client holds no persistent connections
+func (p *OllamaProvider) Close() error {
+	return nil
+}
+
+func (p *OllamaProvider) GetProviderName() string {
+	return "ollama"
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"` // "system", "user" or "assistant"
+	Content string `json:"content"`
+}
+
+type ollamaChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []ollamaMessage        `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (p *OllamaProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	// Ollama models vary in native tool support, so tools are described in the
+	// system prompt and called with the TOOL_CALL text convention
+	messages := []ollamaMessage{{Role: "system", Content: buildSystemPromptWithTools(tools, prompt)}}
+
+	// Add conversation history
+	for _, msg := range conversationHistory {
+		if msg.Role == "user" || msg.Role == "assistant" {
+			if msg.Content != "" {
+				messages = append(messages, ollamaMessage{Role: msg.Role, Content: msg.Content})
+			}
+		}
+	}
+
+	// Add current prompt
+	messages = append(messages, ollamaMessage{Role: "user", Content: prompt})
+
+	req := ollamaChatRequest{
+		Model:    p.model,
+		Messages: messages,
+	}
+
+	// Apply per-request sampling parameters
+	if genConfig != nil {
+		req.Options = make(map[string]interface{})
+		if genConfig.Temperature != nil {
+			req.Options["temperature"] = *genConfig.Temperature
+		}
+		if genConfig.TopP != nil {
+			req.Options["top_p"] = *genConfig.TopP
+		}
+		if genConfig.TopK != nil {
+			req.Options["top_k"] = *genConfig.TopK
+		}
+		if genConfig.MaxTokens != nil {
+			req.Options["num_predict"] = *genConfig.MaxTokens
+		}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Ollama request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("cannot connect to Ollama at %s: Ollama doesn't appear to be running (start it with 'ollama serve')", p.baseURL)
+		}
+		return nil, fmt.Errorf("Ollama API error: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Ollama response: %w", err)
+	}
+
+	var resp ollamaChatResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		if httpResp.StatusCode != http.StatusOK {
+			return nil, &HTTPStatusError{
+				StatusCode: httpResp.StatusCode,
+				Message:    fmt.Sprintf("Ollama API error (%d): %s", httpResp.StatusCode, string(respBody)),
+			}
+		}
+		return nil, fmt.Errorf("failed to parse Ollama response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK || resp.Error != "" {
+		if httpResp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("Ollama model %q is not available (pull it with 'ollama pull %s'): %s", p.model, p.model, resp.Error)
+		}
+		return nil, &HTTPStatusError{
+			StatusCode: httpResp.StatusCode,
+			Message:    fmt.Sprintf("Ollama API error (%d): %s", httpResp.StatusCode, resp.Error),
+		}
+	}
+
+	response := &Response{
+		Content:      resp.Message.Content,
+		FinishReason: resp.DoneReason,
+		Usage: &Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+	}
+
+	// Parse tool calls in the format: TOOL_CALL: tool_name({"arg": "value"})
+	if toolCalls := extractToolCalls(resp.Message.Content, tools); len(toolCalls) > 0 {
+		response.ToolCalls = toolCalls
+	}
+
+	return response, nil
+}
@@ -0,0 +1,105 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// newTestOllamaServer answers /api/chat with status and reply, recording the last request
+func newTestOllamaServer(t *testing.T, status int, reply string) (*httptest.Server, *ollamaChatRequest) {
+	t.Helper()
+	var req ollamaChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("request path = %s, want /api/chat", r.URL.Path)
+		}
+		req = ollamaChatRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server, &req
+}
+
+func TestOllamaChatExtractsToolCalls(t *testing.T) {
+	server, req := newTestOllamaServer(t, http.StatusOK, `{
+		"message": {"role": "assistant", "content": "TOOL_CALL: create_resource({\"name\": \"db\"})"},
+		"done_reason": "stop", "prompt_eval_count": 20, "eval_count": 5
+	}`)
+	provider, err := NewOllamaProvider(server.URL+"/", "llama3.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := []*mcp.Tool{{Name: "create_resource", Description: "Create a resource"}}
+	history := []Message{
+		{Role: "user", Content: "hello"},
+		{Role: "assistant", Content: "hi"},
+		{Role: "user", ToolResults: []ToolResult{{ToolCallID: "1", Content: "ignored"}}},
+	}
+
+	resp, err := provider.Chat(context.Background(), "create a db", tools, history, nil)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "create_resource" || resp.ToolCalls[0].Arguments["name"] != "db" {
+		t.Errorf("ToolCalls = %+v, want create_resource(name=db)", resp.ToolCalls)
+	}
+	if resp.Usage.PromptTokens != 20 || resp.Usage.CompletionTokens != 5 || resp.Usage.TotalTokens != 25 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+
+	wantRoles := []string{"system", "user", "assistant", "user"}
+	if len(req.Messages) != len(wantRoles) {
+		t.Fatalf("sent %d messages, want %d: %+v", len(req.Messages), len(wantRoles), req.Messages)
+	}
+	for i, role := range wantRoles {
+		if req.Messages[i].Role != role {
+			t.Errorf("message %d role = %s, want %s", i, req.Messages[i].Role, role)
+		}
+	}
+	if !strings.Contains(req.Messages[0].Content, "create_resource") {
+		t.Error("system prompt doesn't describe the tools")
+	}
+	if req.Messages[3].Content != "create a db" || req.Model != "llama3.1" || req.Stream {
+		t.Errorf("request = %+v", req)
+	}
+}
+
+func TestOllamaChatErrors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	missingModel, _ := newTestOllamaServer(t, http.StatusNotFound, `{"error": "model \"llama9\" not found"}`)
+	overloaded, _ := newTestOllamaServer(t, http.StatusServiceUnavailable, `{"error": "server busy"}`)
+
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+	}{
+		{"not running", closed.URL, "doesn't appear to be running"},
+		{"model not pulled", missingModel.URL, "ollama pull llama9"},
+		{"server error", overloaded.URL, "server busy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewOllamaProvider(tt.baseURL, "llama9")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = provider.Chat(context.Background(), "hi", nil, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Chat error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,25 @@
 package ai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
 	openai "github.com/sashabaranov/go-openai"
 )
 
 type OpenAIProvider struct {
-	client *openai.Client
-	model  string
+	client          *openai.Client
+	model           string
+	reasoningEffort string // "low", "medium" or "high"; only sent to reasoning models
 }
 
-func NewOpenAIProvider(apiKey, model string) (*OpenAIProvider, error) {
+func NewOpenAIProvider(apiKey, model, reasoningEffort string) (*OpenAIProvider, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("OpenAI API key is required")
 	}
@@ -23,25 +28,94 @@ func NewOpenAIProvider(apiKey, model string) (*OpenAIProvider, error) {
 		model = openai.GPT4TurboPreview // Default to GPT-4 Turbo
 	}
 
-	client := openai.NewClient(apiKey)
-
 	return &OpenAIProvider{
-		client: client,
-		model:  model,
+		client:          newOpenAIClient(openai.DefaultConfig(apiKey)),
+		model:           model,
+		reasoningEffort: reasoningEffort,
 	}, nil
 }
 
+// newOpenAIClient creates a client whose requests can carry an explicit zero temperature
+func newOpenAIClient(config openai.ClientConfig) *openai.Client {
+	base := config.HTTPClient
+	if base == nil {
+		base = &http.Client{}
+	}
+	config.HTTPClient = &zeroTemperatureDoer{base: base}
+	return openai.NewClientWithConfig(config)
+}
+
+// explicitZeroTemperatureKey marks a request context whose temperature is exactly 0
+type explicitZeroTemperatureKey struct{}
+
+func withExplicitZeroTemperature(ctx context.Context) context.Context {
+	return context.WithValue(ctx, explicitZeroTemperatureKey{}, true)
+}
+
+// zeroTemperatureDoer adds "temperature": 0 to chat requests that ask for it. The SDK's
+// Temperature is a plain float32 with omitempty and it has no pointer or param helper, so a
+// zero would otherwise be dropped and the API would apply its default of 1
+type zeroTemperatureDoer struct {
+	base openai.HTTPDoer
+}
+
+func (d *zeroTemperatureDoer) Do(req *http.Request) (*http.Response, error) {
+	if zero, _ := req.Context().Value(explicitZeroTemperatureKey{}).(bool); !zero || req.Body == nil {
+		return d.base.Do(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAI request: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		fields["temperature"] = json.RawMessage("0")
+		if patched, err := json.Marshal(fields); err == nil {
+			body = patched
+		}
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+	return d.base.Do(req)
+}
+
+// isReasoningModel reports whether the model belongs to the o-series reasoning
+// families, which reject sampling parameters and the system role
+func isReasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if model == prefix || strings.HasPrefix(model, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// Close is a no-op; the OpenAI client holds no persistent connections
+func (p *OpenAIProvider) Close() error {
+	return nil
+}
+
 func (p *OpenAIProvider) GetProviderName() string {
 	return "openai"
 }
 
-func (p *OpenAIProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message) (*Response, error) {
+func (p *OpenAIProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	reasoning := isReasoningModel(p.model)
+
 	// Build messages from conversation history
 	messages := []openai.ChatCompletionMessage{}
 
-	// Add system message
+	// Add system message (reasoning models take instructions via the developer role)
+	systemRole := openai.ChatMessageRoleSystem
+	if reasoning {
+		systemRole = openai.ChatMessageRoleDeveloper
+	}
 	messages = append(messages, openai.ChatCompletionMessage{
-		Role:    openai.ChatMessageRoleSystem,
+		Role:    systemRole,
 		Content: "You are a helpful AI assistant that can interact with CloudGenie infrastructure management platform. You have access to various tools to help manage cloud resources. When asked to perform operations, use the available tools to accomplish the task.",
 	})
 
@@ -73,7 +147,7 @@ func (p *OpenAIProvider) Chat(ctx context.Context, prompt string, tools []*mcp.T
 		for _, tool := range tools {
 			openaiTools = append(openaiTools, openai.Tool{
 				Type: openai.ToolTypeFunction,
-				Function: openai.FunctionDefinition{
+				Function: &openai.FunctionDefinition{
 					Name:        tool.Name,
 					Description: tool.Description,
 					Parameters:  tool.InputSchema,
@@ -93,6 +167,34 @@ func (p *OpenAIProvider) Chat(ctx context.Context, prompt string, tools []*mcp.T
 		req.ToolChoice = "auto"
 	}
 
+	// Reasoning models don't accept temperature/top_p; they take a reasoning effort instead
+	if reasoning {
+		req.ReasoningEffort = p.reasoningEffort
+	}
+
+	// Apply per-request sampling parameters (OpenAI has no top_k)
+	if genConfig != nil {
+		if !reasoning {
+			if genConfig.Temperature != nil {
+				req.Temperature = *genConfig.Temperature
+				if req.Temperature == 0 {
+					// omitempty would drop a zero; zeroTemperatureDoer sends it explicitly
+					ctx = withExplicitZeroTemperature(ctx)
+				}
+			}
+			if genConfig.TopP != nil {
+				req.TopP = *genConfig.TopP
+			}
+		}
+		if genConfig.MaxTokens != nil {
+			if reasoning {
+				req.MaxCompletionTokens = int(*genConfig.MaxTokens)
+			} else {
+				req.MaxTokens = int(*genConfig.MaxTokens)
+			}
+		}
+	}
+
 	resp, err := p.client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI API error: %w", err)
@@ -113,6 +215,10 @@ func (p *OpenAIProvider) Chat(ctx context.Context, prompt string, tools []*mcp.T
 		},
 	}
 
+	if resp.Usage.CompletionTokensDetails != nil {
+		response.Usage.ReasoningTokens = resp.Usage.CompletionTokensDetails.ReasoningTokens
+	}
+
 	// Handle tool calls if present
 	if len(choice.Message.ToolCalls) > 0 {
 		for _, tc := range choice.Message.ToolCalls {
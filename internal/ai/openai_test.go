@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// newTestOpenAIProvider returns a provider for model that sends its requests to a test
+// server, and the decoded body of the last request
+func newTestOpenAIProvider(t *testing.T, model string) (*OpenAIProvider, *map[string]interface{}) {
+	t.Helper()
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = server.URL + "/v1"
+	return &OpenAIProvider{client: newOpenAIClient(config), model: model, reasoningEffort: "low"}, &body
+}
+
+func TestOpenAIChatTemperature(t *testing.T) {
+	zero, warm := float32(0), float32(0.5)
+	tests := []struct {
+		name        string
+		model       string
+		temperature *float32
+		want        interface{} // nil means the field must be absent
+	}{
+		{"explicit zero is sent", "gpt-4o", &zero, float64(0)},
+		{"non-zero is sent", "gpt-4o", &warm, float64(0.5)},
+		{"unset is omitted", "gpt-4o", nil, nil},
+		{"reasoning model never gets a temperature", "o3-mini", &warm, nil},
+		{"reasoning model ignores a zero temperature", "o3-mini", &zero, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, body := newTestOpenAIProvider(t, tt.model)
+			if _, err := provider.Chat(context.Background(), "hi", nil, nil, &GenerationConfig{Temperature: tt.temperature}); err != nil {
+				t.Fatalf("Chat: %v", err)
+			}
+
+			got, present := (*body)["temperature"]
+			if tt.want == nil {
+				if present {
+					t.Errorf("temperature = %v, want it omitted", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("temperature = %v (present %v), want %v", got, present, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIChatReasoningEffort(t *testing.T) {
+	provider, body := newTestOpenAIProvider(t, "o3-mini")
+	if _, err := provider.Chat(context.Background(), "hi", nil, nil, nil); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if got := (*body)["reasoning_effort"]; got != "low" {
+		t.Errorf("reasoning_effort = %v, want low", got)
+	}
+}
+
+func TestOpenAIChatGenerationConfig(t *testing.T) {
+	topP, topK, maxTokens := float32(0.5), int32(8), int32(256)
+	tests := []struct {
+		name  string
+		model string
+		want  map[string]interface{} // nil values must be absent
+	}{
+		{"chat model", "gpt-4o", map[string]interface{}{
+			"top_p": float64(0.5), "max_tokens": float64(256), "max_completion_tokens": nil, "top_k": nil,
+		}},
+		{"reasoning model", "o3-mini", map[string]interface{}{
+			"top_p": nil, "max_tokens": nil, "max_completion_tokens": float64(256), "top_k": nil,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, body := newTestOpenAIProvider(t, tt.model)
+			genConfig := &GenerationConfig{TopP: &topP, TopK: &topK, MaxTokens: &maxTokens}
+			if _, err := provider.Chat(context.Background(), "hi", nil, nil, genConfig); err != nil {
+				t.Fatalf("Chat: %v", err)
+			}
+			for field, want := range tt.want {
+				got, present := (*body)[field]
+				if want == nil && present {
+					t.Errorf("%s = %v, want it omitted", field, got)
+				} else if want != nil && got != want {
+					t.Errorf("%s = %v, want %v", field, got, want)
+				}
+			}
+		})
+	}
+}
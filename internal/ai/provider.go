@@ -9,16 +9,42 @@ import (
 
 // Provider defines the interface for AI providers
 type Provider interface {
-	Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message) (*Response, error)
+	Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error)
 	GetProviderName() string
+	// Close releases any connections held by the provider
+	Close() error
+}
+
+// ProviderFactory builds a provider for the given model; an empty model means the configured default
+type ProviderFactory func(model string) (Provider, error)
+
+// Compile-time checks that every provider satisfies Provider
+var (
+	_ Provider = (*OpenAIProvider)(nil)
+	_ Provider = (*AnthropicProvider)(nil)
+	_ Provider = (*GeminiProvider)(nil)
+	_ Provider = (*GleanProvider)(nil)
+	_ Provider = (*BedrockProvider)(nil)
+	_ Provider = (*OllamaProvider)(nil)
+	_ Provider = (*CohereProvider)(nil)
+	_ Provider = (*MistralProvider)(nil)
+)
+
+// GenerationConfig overrides a provider's sampling parameters for one request.
+// Nil fields keep the provider's defaults; providers ignore fields they don't support
+type GenerationConfig struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	TopP        *float32 `json:"top_p,omitempty"`
+	TopK        *int32   `json:"top_k,omitempty"`
+	MaxTokens   *int32   `json:"max_tokens,omitempty"`
 }
 
 // Message represents a conversation message
 type Message struct {
-	Role    string                 `json:"role"`    // "user", "assistant", "system"
-	Content string                 `json:"content"`
-	ToolCalls []ToolCall           `json:"tool_calls,omitempty"`
-	ToolResults []ToolResult       `json:"tool_results,omitempty"`
+	Role        string       `json:"role"` // "user", "assistant", "system"
+	Content     string       `json:"content"`
+	ToolCalls   []ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
 }
 
 // ToolCall represents a tool call request from the AI
@@ -37,10 +63,10 @@ type ToolResult struct {
 
 // Response represents the AI response
 type Response struct {
-	Content     string       `json:"content"`
-	ToolCalls   []ToolCall   `json:"tool_calls,omitempty"`
-	FinishReason string      `json:"finish_reason"`
-	Usage       *Usage       `json:"usage,omitempty"`
+	Content      string     `json:"content"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	FinishReason string     `json:"finish_reason"`
+	Usage        *Usage     `json:"usage,omitempty"`
 }
 
 // Usage represents token usage information
@@ -48,22 +74,40 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"` // Hidden reasoning tokens (o-series models), included in CompletionTokens
+}
+
+// ProviderConfig holds the settings NewProvider needs. Providers ignore fields they don't use
+type ProviderConfig struct {
+	APIKey  string
+	Model   string
+	Region  string // AWS region, for bedrock
+	BaseURL string // Server URL, for ollama; empty means the local default
 }
 
 // NewProvider creates a new AI provider based on the provider name
-func NewProvider(providerName, apiKey, model string) (Provider, error) {
+func NewProvider(providerName string, cfg ProviderConfig) (Provider, error) {
 	switch providerName {
 	case "openai", "":
-		return NewOpenAIProvider(apiKey, model)
+		return NewOpenAIProvider(cfg.APIKey, cfg.Model, "")
 	case "anthropic":
-		return NewAnthropicProvider(apiKey, model)
+		return NewAnthropicProvider(cfg.APIKey, cfg.Model)
 	case "gemini":
-		return NewGeminiProvider(apiKey, model)
+		return NewGeminiProvider(cfg.APIKey, cfg.Model, ToolCallFormatNative)
 	case "glean":
 		// For Glean, we need API URL as well, so we'll use a special format
 		// apiKey format can be "key" or we need to pass apiURL separately
 		// We'll need to modify this to accept apiURL - for now, use default
-		return NewGleanProvider(apiKey, "", model)
+		return NewGleanProvider(cfg.APIKey, "", cfg.Model, ToolCallFormatText)
+	case "bedrock":
+		// Bedrock authenticates through the default AWS credential chain, so only the region is needed
+		return NewBedrockProvider(cfg.Region, cfg.Model)
+	case "ollama":
+		return NewOllamaProvider(cfg.BaseURL, cfg.Model)
+	case "cohere":
+		return NewCohereProvider(cfg.APIKey, cfg.Model)
+	case "mistral":
+		return NewMistralProvider(cfg.APIKey, cfg.Model)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", providerName)
 	}
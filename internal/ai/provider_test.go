@@ -0,0 +1,53 @@
+package ai
+
+import "testing"
+
+func TestNewProviderSatisfiesProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ProviderConfig
+	}{
+		{"openai", ProviderConfig{APIKey: "test-key"}},
+		{"anthropic", ProviderConfig{APIKey: "test-key"}},
+		{"gemini", ProviderConfig{APIKey: "test-key"}},
+		{"bedrock", ProviderConfig{Region: "us-east-1"}},
+		{"ollama", ProviderConfig{BaseURL: "http://localhost:11434"}},
+		{"cohere", ProviderConfig{APIKey: "test-key"}},
+		{"mistral", ProviderConfig{APIKey: "test-key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var provider Provider
+			provider, err := NewProvider(tt.name, tt.cfg)
+			if err != nil {
+				t.Fatalf("NewProvider(%q): %v", tt.name, err)
+			}
+			defer provider.Close()
+			if got := provider.GetProviderName(); got != tt.name {
+				t.Errorf("GetProviderName() = %q, want %q", got, tt.name)
+			}
+		})
+	}
+
+	// NewProvider has no way to pass a Glean instance, so construct it directly
+	var provider Provider
+	provider, err := NewGleanProvider("test-key", "test-company", "", ToolCallFormatText)
+	if err != nil {
+		t.Fatalf("NewGleanProvider: %v", err)
+	}
+	if got := provider.GetProviderName(); got != "glean" {
+		t.Errorf("GetProviderName() = %q, want %q", got, "glean")
+	}
+}
+
+func TestNewProviderRejectsUnknownProvider(t *testing.T) {
+	if _, err := NewProvider("unknown", ProviderConfig{APIKey: "test-key"}); err == nil {
+		t.Error("NewProvider(\"unknown\") succeeded, want an error")
+	}
+}
+
+func TestNewProviderDoesNotUseAPIKeyAsRegion(t *testing.T) {
+	if _, err := NewProvider("bedrock", ProviderConfig{APIKey: "us-east-1"}); err == nil {
+		t.Error("NewProvider(\"bedrock\") without a region succeeded, want an error")
+	}
+}
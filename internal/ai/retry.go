@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	bedrocktypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/gleanwork/api-client-go/models/apierrors"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPStatusError is returned by providers that call their API over plain HTTP,
+// so callers can tell rate limits and server errors from bad requests
+type HTTPStatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return e.Message
+}
+
+// RateLimiter meters calls to an AI provider. Wait blocks until a call to provider may be
+// made, or returns an error if the budget is exhausted
+type RateLimiter interface {
+	Wait(ctx context.Context, provider string) error
+}
+
+// RetryConfig controls how provider calls are retried on transient errors
+type RetryConfig struct {
+	MaxAttempts int           // Total attempts including the first; 1 or less disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled on each later retry
+	MaxDelay    time.Duration // Upper bound on a single delay
+
+	// Limiter, if set, is waited on before every retry so retries count against the
+	// provider's rate limit. The first attempt is metered by the caller
+	Limiter RateLimiter
+}
+
+type retryingProvider struct {
+	Provider
+	config RetryConfig
+}
+
+// WithRetry wraps a provider so Chat is retried with exponential backoff and jitter on
+// retryable errors (rate limits, timeouts, 5xx). Other errors are returned immediately
+func WithRetry(provider Provider, config RetryConfig) Provider {
+	if config.MaxAttempts <= 1 {
+		return provider
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = 30 * time.Second
+	}
+	return &retryingProvider{Provider: provider, config: config}
+}
+
+func (p *retryingProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	delay := p.config.BaseDelay
+	for attempt := 1; ; attempt++ {
+		response, err := p.Provider.Chat(ctx, prompt, tools, conversationHistory, genConfig)
+		if err == nil || attempt >= p.config.MaxAttempts || !IsRetryableError(err) || ctx.Err() != nil {
+			return response, err
+		}
+
+		// A negative base delay (or an overflowing doubling) would make rand.Int63n panic
+		if delay < 0 {
+			delay = 0
+		}
+		// Full jitter: wait a random duration up to the current backoff
+		wait := time.Duration(rand.Int63n(int64(delay) + 1))
+		log.Printf("%s call failed (attempt %d/%d), retrying in %s: %v",
+			p.GetProviderName(), attempt, p.config.MaxAttempts, wait.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+
+		delay *= 2
+		if delay > p.config.MaxDelay {
+			delay = p.config.MaxDelay
+		}
+
+		if p.config.Limiter != nil {
+			if err := p.config.Limiter.Wait(ctx, p.GetProviderName()); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// IsRetryableError reports whether a provider error is transient: a rate limit,
+// a timeout, or a server-side failure
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		return isRetryableStatus(httpErr.StatusCode)
+	}
+
+	// OpenAI
+	var openaiAPIErr *openai.APIError
+	if errors.As(err, &openaiAPIErr) {
+		return isRetryableStatus(openaiAPIErr.HTTPStatusCode)
+	}
+	var openaiReqErr *openai.RequestError
+	if errors.As(err, &openaiReqErr) {
+		return isRetryableStatus(openaiReqErr.HTTPStatusCode)
+	}
+
+	// Glean
+	var gleanErr *apierrors.APIError
+	if errors.As(err, &gleanErr) {
+		return isRetryableStatus(gleanErr.StatusCode)
+	}
+
+	// Bedrock
+	var throttling *bedrocktypes.ThrottlingException
+	var unavailable *bedrocktypes.ServiceUnavailableException
+	var internal *bedrocktypes.InternalServerException
+	var modelTimeout *bedrocktypes.ModelTimeoutException
+	var modelNotReady *bedrocktypes.ModelNotReadyException
+	if errors.As(err, &throttling) || errors.As(err, &unavailable) || errors.As(err, &internal) ||
+		errors.As(err, &modelTimeout) || errors.As(err, &modelNotReady) {
+		return true
+	}
+
+	// Gemini (gRPC)
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		switch s.Code() {
+		case codes.ResourceExhausted, codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Aborted:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= 500
+}
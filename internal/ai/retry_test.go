@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// flakyProvider fails with each of errs in turn, then succeeds
+type flakyProvider struct {
+	errs  []error
+	calls int
+}
+
+func (p *flakyProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []Message, genConfig *GenerationConfig) (*Response, error) {
+	p.calls++
+	if p.calls <= len(p.errs) {
+		return nil, p.errs[p.calls-1]
+	}
+	return &Response{Content: "ok"}, nil
+}
+
+func (p *flakyProvider) GetProviderName() string { return "flaky" }
+func (p *flakyProvider) Close() error            { return nil }
+
+// countingLimiter counts Wait calls and fails once allowed is used up
+type countingLimiter struct {
+	waits   int
+	allowed int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context, provider string) error {
+	l.waits++
+	if l.waits > l.allowed {
+		return errors.New("rate limited")
+	}
+	return nil
+}
+
+func TestWithRetry(t *testing.T) {
+	unavailable := &HTTPStatusError{StatusCode: http.StatusServiceUnavailable, Message: "503"}
+	tooMany := &HTTPStatusError{StatusCode: http.StatusTooManyRequests, Message: "429"}
+	badRequest := &HTTPStatusError{StatusCode: http.StatusBadRequest, Message: "400"}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		config    RetryConfig
+		wantCalls int
+		wantErr   error
+	}{
+		{"fails twice then succeeds", []error{unavailable, tooMany}, RetryConfig{MaxAttempts: 3}, 3, nil},
+		{"bad request is not retried", []error{badRequest}, RetryConfig{MaxAttempts: 3}, 1, badRequest},
+		{"gives up after max attempts", []error{unavailable, unavailable, unavailable}, RetryConfig{MaxAttempts: 2}, 2, unavailable},
+		{"one attempt disables retries", []error{unavailable}, RetryConfig{MaxAttempts: 1}, 1, unavailable},
+		{"negative base delay retries immediately", []error{unavailable}, RetryConfig{MaxAttempts: 2, BaseDelay: -time.Second}, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyProvider{errs: tt.errs}
+			_, err := WithRetry(inner, tt.config).Chat(context.Background(), "hi", nil, nil, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if inner.calls != tt.wantCalls {
+				t.Errorf("provider called %d times, want %d", inner.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetryWaitsOnLimiterBeforeEachRetry(t *testing.T) {
+	unavailable := &HTTPStatusError{StatusCode: http.StatusServiceUnavailable, Message: "503"}
+
+	limiter := &countingLimiter{allowed: 10}
+	inner := &flakyProvider{errs: []error{unavailable, unavailable}}
+	if _, err := WithRetry(inner, RetryConfig{MaxAttempts: 3, Limiter: limiter}).Chat(context.Background(), "hi", nil, nil, nil); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if limiter.waits != 2 {
+		t.Errorf("limiter waited %d times, want 2 (one per retry)", limiter.waits)
+	}
+
+	// An exhausted limiter stops the retries instead of exceeding the provider's budget
+	limiter = &countingLimiter{allowed: 0}
+	inner = &flakyProvider{errs: []error{unavailable, unavailable}}
+	if _, err := WithRetry(inner, RetryConfig{MaxAttempts: 3, Limiter: limiter}).Chat(context.Background(), "hi", nil, nil, nil); err == nil {
+		t.Fatal("expected the limiter error")
+	}
+	if inner.calls != 1 {
+		t.Errorf("provider called %d times, want 1", inner.calls)
+	}
+}
+
+func TestWithRetryStopsOnCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inner := &flakyProvider{errs: []error{&HTTPStatusError{StatusCode: http.StatusBadGateway, Message: "502"}}}
+	if _, err := WithRetry(inner, RetryConfig{MaxAttempts: 3}).Chat(ctx, "hi", nil, nil, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if inner.calls != 1 {
+		t.Errorf("provider called %d times, want 1", inner.calls)
+	}
+}
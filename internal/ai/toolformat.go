@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// ToolCallFormat is the text convention prompt-based providers use to request tool calls
+type ToolCallFormat string
+
+const (
+	// ToolCallFormatText is TOOL_CALL: tool_name({"arg": "value"})
+	ToolCallFormatText ToolCallFormat = "tool_call_text"
+	// ToolCallFormatJSONBlock is a fenced ```json block holding {"tool": "...", "arguments": {...}}
+	ToolCallFormatJSONBlock ToolCallFormat = "json_block"
+	// ToolCallFormatXMLTag is <tool_call name="tool_name">{"arg": "value"}</tool_call>
+	ToolCallFormatXMLTag ToolCallFormat = "xml_tag"
+	// ToolCallFormatNative declares tools through the provider's function-calling API,
+	// falling back to the text format when the model answers in text
+	ToolCallFormatNative ToolCallFormat = "native"
+)
+
+var (
+	textExamplePattern = regexp.MustCompile(`TOOL_CALL:\s*([a-zA-Z0-9_-]+)\((.*)\)`)
+	textLinePattern    = regexp.MustCompile(`(?m)^\s*TOOL_CALL:.*$`)
+	jsonBlockPattern   = regexp.MustCompile("(?s)```(?:json)?\\s*(\\{.*?\\})\\s*```")
+	xmlTagPattern      = regexp.MustCompile(`(?s)<tool_call\s+name="([a-zA-Z0-9_-]+)"\s*>(.*?)</tool_call>`)
+)
+
+// ParseToolCallFormat validates a configured format name; empty means the text format
+func ParseToolCallFormat(name string) (ToolCallFormat, error) {
+	switch ToolCallFormat(name) {
+	case "", ToolCallFormatText:
+		return ToolCallFormatText, nil
+	case ToolCallFormatJSONBlock, ToolCallFormatXMLTag, ToolCallFormatNative:
+		return ToolCallFormat(name), nil
+	default:
+		return "", fmt.Errorf("unsupported tool call format %q (use native, tool_call_text, json_block or xml_tag)", name)
+	}
+}
+
+// renderToolCall renders a single example call in the format
+func (f ToolCallFormat) renderToolCall(name, argsJSON string) string {
+	switch f {
+	case ToolCallFormatJSONBlock:
+		return fmt.Sprintf("```json\n{\"tool\": \"%s\", \"arguments\": %s}\n```", name, argsJSON)
+	case ToolCallFormatXMLTag:
+		return fmt.Sprintf("<tool_call name=\"%s\">%s</tool_call>", name, argsJSON)
+	default:
+		return fmt.Sprintf("TOOL_CALL: %s(%s)", name, argsJSON)
+	}
+}
+
+// applyToolCallFormat rewrites the TOOL_CALL examples in a system prompt into the format
+func applyToolCallFormat(prompt string, format ToolCallFormat) string {
+	if format == ToolCallFormatText || format == "" {
+		return prompt
+	}
+	prompt = strings.NewReplacer(
+		"the TOOL_CALL format", "the tool call format",
+		"TOOL_CALL lines", "tool calls",
+	).Replace(prompt)
+	return textExamplePattern.ReplaceAllStringFunc(prompt, func(match string) string {
+		parts := textExamplePattern.FindStringSubmatch(match)
+		return format.renderToolCall(parts[1], parts[2])
+	})
+}
+
+// extractToolCallsForFormat parses tool calls written in a structured format. The text
+// format is handled by each provider's own extractor.
+func extractToolCallsForFormat(format ToolCallFormat, content string, tools []*mcp.Tool) []ToolCall {
+	validTools := make(map[string]bool)
+	for _, tool := range tools {
+		validTools[tool.Name] = true
+	}
+
+	var toolCalls []ToolCall
+	addCall := func(name, argsJSON string) {
+		if !validTools[name] {
+			return
+		}
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(argsJSON)), &args); err != nil || args == nil {
+			args = make(map[string]interface{})
+		}
+		toolCalls = append(toolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", len(toolCalls)+1),
+			Name:      name,
+			Arguments: args,
+		})
+	}
+
+	switch format {
+	case ToolCallFormatJSONBlock:
+		for _, match := range jsonBlockPattern.FindAllStringSubmatch(content, -1) {
+			var block struct {
+				Tool      string          `json:"tool"`
+				Arguments json.RawMessage `json:"arguments"`
+			}
+			if err := json.Unmarshal([]byte(match[1]), &block); err != nil || block.Tool == "" {
+				continue
+			}
+			addCall(block.Tool, string(block.Arguments))
+		}
+	case ToolCallFormatXMLTag:
+		for _, match := range xmlTagPattern.FindAllStringSubmatch(content, -1) {
+			addCall(match[1], match[2])
+		}
+	}
+
+	return toolCalls
+}
+
+// StripToolCalls removes tool-call markup in any supported format from assistant content,
+// leaving only the narrative text
+func StripToolCalls(content string) string {
+	content = textLinePattern.ReplaceAllString(content, "")
+	content = xmlTagPattern.ReplaceAllString(content, "")
+	content = jsonBlockPattern.ReplaceAllStringFunc(content, func(block string) string {
+		if strings.Contains(block, `"tool"`) {
+			return ""
+		}
+		return block
+	})
+	return strings.TrimSpace(content)
+}
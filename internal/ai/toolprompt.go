@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// DefaultToolPromptTokenBudget is the estimated token budget for the tool list in
+// text tool-call system prompts
+const DefaultToolPromptTokenBudget = 6000
+
+var toolPromptBudget atomic.Int64
+
+func init() {
+	toolPromptBudget.Store(DefaultToolPromptTokenBudget)
+}
+
+// SetToolPromptTokenBudget sets the estimated token budget (about four characters per
+// token) for the tool list in text tool-call system prompts. Non-positive values restore the default
+func SetToolPromptTokenBudget(tokens int) {
+	if tokens <= 0 {
+		tokens = DefaultToolPromptTokenBudget
+	}
+	toolPromptBudget.Store(int64(tokens))
+}
+
+// toolDetail is how much of a tool's definition is rendered into a prompt
+type toolDetail int
+
+const (
+	toolDetailFull  toolDetail = iota // description and every parameter with its type and description
+	toolDetailBrief                   // first sentence of the description and parameter names
+	toolDetailName                    // name only
+)
+
+// renderToolsWithinBudget renders every tool in full when that fits the budget. Otherwise all
+// tools start as names only and, most relevant to query first, are upgraded to full or brief
+// detail while the budget allows. Tools keep their original order in the output
+func renderToolsWithinBudget(tools []*mcp.Tool, query string, render func(*mcp.Tool, toolDetail) string) string {
+	budget := int(toolPromptBudget.Load())
+
+	full := make([]string, len(tools))
+	total := 0
+	for i, tool := range tools {
+		full[i] = render(tool, toolDetailFull)
+		total += estimateTokens(full[i])
+	}
+	if total <= budget {
+		return strings.Join(full, "")
+	}
+
+	rendered := make([]string, len(tools))
+	cost := 0
+	for i, tool := range tools {
+		rendered[i] = render(tool, toolDetailName)
+		cost += estimateTokens(rendered[i])
+	}
+
+	for _, i := range rankToolsByRelevance(tools, query) {
+		current := estimateTokens(rendered[i])
+		if fullCost := estimateTokens(full[i]); cost-current+fullCost <= budget {
+			rendered[i] = full[i]
+			cost += fullCost - current
+			continue
+		}
+		brief := render(tools[i], toolDetailBrief)
+		if briefCost := estimateTokens(brief); cost-current+briefCost <= budget {
+			rendered[i] = brief
+			cost += briefCost - current
+		}
+	}
+
+	return strings.Join(rendered, "") + "\n(Less relevant tools are abbreviated to save space; ask for details before calling one if its parameters are unclear.)\n"
+}
+
+// rankToolsByRelevance returns tool indexes ordered by how many query words appear in each
+// tool's name (counted twice) and description, keeping the original order for ties
+func rankToolsByRelevance(tools []*mcp.Tool, query string) []int {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if len(word) >= 3 {
+			words = append(words, word)
+		}
+	}
+
+	scores := make([]int, len(tools))
+	order := make([]int, len(tools))
+	for i, tool := range tools {
+		order[i] = i
+		name := strings.ToLower(tool.Name)
+		description := strings.ToLower(tool.Description)
+		for _, word := range words {
+			if strings.Contains(name, word) {
+				scores[i] += 2
+			}
+			if strings.Contains(description, word) {
+				scores[i]++
+			}
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	return order
+}
+
+// toolParamNames returns a tool's parameter names, sorted, and its required parameters
+func toolParamNames(tool *mcp.Tool) (params, required []string) {
+	schema, ok := tool.InputSchema.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for name := range props {
+			params = append(params, name)
+		}
+		sort.Strings(params)
+	}
+	if req, ok := schema["required"].([]interface{}); ok {
+		for _, r := range req {
+			if name, ok := r.(string); ok {
+				required = append(required, name)
+			}
+		}
+	}
+	return params, required
+}
+
+// firstSentence returns text up to the end of its first sentence or line
+func firstSentence(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	if i := strings.Index(text, ". "); i >= 0 {
+		text = text[:i+1]
+	}
+	return strings.TrimSpace(text)
+}
+
+// estimateTokens roughly estimates the token count of text at four characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -3,7 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/logging"
 	"github.com/joho/godotenv"
 )
 
@@ -13,49 +17,225 @@ type Config struct {
 	ServerPort string
 
 	// AI Provider configuration
-	DefaultAIProvider string // "openai", "anthropic", "gemini", or "glean"
-	OpenAIAPIKey      string
-	OpenAIModel       string
-	AnthropicAPIKey   string
-	AnthropicModel    string
-	GeminiAPIKey      string
-	GeminiModel       string
-	GleanAPIKey       string
-	GleanInstance     string // Company instance name (e.g., "your-company")
-	GleanModel        string
+	DefaultAIProvider     string // "openai", "anthropic", "gemini", "glean", "bedrock", "ollama", "cohere", or "mistral"
+	OpenAIAPIKey          string
+	OpenAIModel           string
+	OpenAIReasoningEffort string // "low", "medium" or "high"; used by o-series reasoning models
+	AnthropicAPIKey       string
+	AnthropicModel        string
+	GeminiAPIKey          string
+	GeminiModel           string
+	GeminiToolCallFormat  string // "native", "tool_call_text", "json_block" or "xml_tag"
+	GleanAPIKey           string
+	GleanInstance         string // Company instance name (e.g., "your-company")
+	GleanModel            string
+	GleanToolCallFormat   string // "tool_call_text", "json_block" or "xml_tag"
+	BedrockRegion         string // AWS region for Bedrock; credentials come from the default AWS chain
+	BedrockModel          string
+	OllamaBaseURL         string
+	OllamaModel           string   // Setting a model makes Ollama selectable even when it isn't the default
+	EnabledProviders      []string // Allowlist of provider names; empty allows every configured provider
+	CohereAPIKey          string
+	CohereModel           string
+	MistralAPIKey         string
+	MistralModel          string
 
 	// MCP Server configuration
 	MCPServerURL          string
+	MCPClientName         string        // clientInfo.name sent in the initialize handshake
+	MCPClientVersion      string        // clientInfo.version sent in the initialize handshake
+	MCPClientCapabilities []string      // Optional capabilities to advertise: "sampling", "elicitation"
+	MCPKeepAlive          time.Duration // Ping interval for the MCP session (0 = no pings)
+	MCPCallTimeout        time.Duration // Limit for each MCP tool, resource or prompt request (0 = none)
+	MCPConnectTimeout     time.Duration // Limit for the MCP initialize handshake (0 = none)
+	MCPAllowedTools       []string      // If set, the only MCP tools the AI may use
+	MCPDeniedTools        []string      // MCP tools the AI may never use; wins over MCPAllowedTools
+	MCPToolRefresh        time.Duration // How often the tool list is re-read (0 = only at startup)
 	CloudGenieBackendURL  string
 
 	// CORS configuration
-	AllowedOrigins []string
+	AllowedOrigins      []string // Origins allowed without credentials
+	CredentialedOrigins []string // Origins allowed to send credentials (cookies, Authorization)
+
+	// Tool result post-processing: tool name -> JSON fields stripped before the model sees the result
+	ToolResultStripFields map[string][]string
+
+	// Default blueprint per resource-type keyword (e.g. "database" -> "postgres-blueprint"),
+	// used when a create request doesn't name a blueprint
+	DefaultBlueprints map[string]string
+
+	// Chat concurrency configuration
+	MaxConcurrentChats int           // Maximum chats processed at once (0 = unlimited)
+	ChatQueueTimeout   time.Duration // How long excess chats wait for a slot before a 429
+
+	// AI provider rate limiting: provider name -> requests per minute across all clients
+	ProviderRateLimits    map[string]int
+	ProviderRateLimitWait time.Duration // How long a call waits for a token before a 429
+
+	// AI provider retries on transient errors (rate limits, timeouts, 5xx)
+	ProviderRetryAttempts  int           // Total attempts per call including the first (1 = no retries)
+	ProviderRetryBaseDelay time.Duration // Backoff before the first retry, doubled on each retry
+
+	// Conversation history budgets: estimated tokens (chars/4) of history plus prompt sent per call
+	HistoryTokenBudget  int            // Applies to providers without an override
+	HistoryTokenBudgets map[string]int // Provider name -> token budget
+
+	// Estimated tokens for the tool list in text tool-call system prompts; less relevant
+	// tools are abbreviated beyond it
+	ToolPromptTokenBudget int
+
+	// Tool-name prefixes of read-only tools whose results are cached
+	CacheableToolPrefixes []string
+
+	// Orchestration limits
+	MaxToolIterations int           // Tool-calling iterations per chat unless the request overrides it
+	ToolCacheTTL      time.Duration // How long read-only tool results are cached (0 = no caching)
+	BlueprintCacheTTL time.Duration // How long the blueprint catalog is cached (0 = no caching)
+
+	// Blueprint categories or groups exposed through the API and to the AI
+	BlueprintAllowedCategories []string // Empty exposes every category
+	BlueprintDeniedCategories  []string // Never exposed; wins over BlueprintAllowedCategories
+
+	// Reject creates against deprecated blueprints instead of only warning
+	BlockDeprecatedBlueprints bool
+
+	// Conversation persistence; sessions are disabled when MongoDBURI is empty
+	MongoDBURI      string
+	MongoDBDatabase string
+
+	// Logging configuration
+	LogRedactPatterns []string // Field-name fragments whose values are masked in logs
+	LogFormat         string   // "text" or "json"
+
+	// Admin endpoints; disabled when AdminToken is empty
+	AdminToken string
+
+	// Start with mutating operations blocked; toggled at runtime via the admin API
+	MaintenanceMode bool
+
+	settings map[string]Setting // every value read during Load, for Effective
 }
 
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
+	// Record where each value comes from, for the admin config endpoint
+	startRecording()
+
 	// Try to load .env file, but don't fail if it doesn't exist
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		ServerHost:            getEnv("SERVER_HOST", "0.0.0.0"),
-		ServerPort:            getEnv("SERVER_PORT", "8081"),
-		DefaultAIProvider:     getEnv("DEFAULT_AI_PROVIDER", "openai"),
-		OpenAIAPIKey:          getEnv("OPENAI_API_KEY", ""),
-		OpenAIModel:           getEnv("OPENAI_MODEL", "gpt-4-turbo-preview"),
-		AnthropicAPIKey:       getEnv("ANTHROPIC_API_KEY", ""),
-		AnthropicModel:        getEnv("ANTHROPIC_MODEL", "claude-3-5-sonnet-20241022"),
-		GeminiAPIKey:          getEnv("GEMINI_API_KEY", ""),
-		GeminiModel:           getEnv("GEMINI_MODEL", "gemini-1.5-pro"),
-		GleanAPIKey:           getEnv("GLEAN_API_KEY", ""),
-		GleanInstance:         getEnv("GLEAN_INSTANCE", ""),
-		GleanModel:            getEnv("GLEAN_MODEL", "glean-default"),
-		MCPServerURL:          getEnv("MCP_SERVER_URL", "http://localhost:3000"),
-		CloudGenieBackendURL:  getEnv("CLOUDGENIE_BACKEND_URL", "http://localhost:8080"),
-		AllowedOrigins:        []string{getEnv("ALLOWED_ORIGINS", "*")},
-	}
-
-	
+		ServerHost:             getEnv("SERVER_HOST", "0.0.0.0"),
+		ServerPort:             getEnv("SERVER_PORT", "8081"),
+		DefaultAIProvider:      getEnv("DEFAULT_AI_PROVIDER", "openai"),
+		OpenAIAPIKey:           getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:            getEnv("OPENAI_MODEL", "gpt-4-turbo-preview"),
+		OpenAIReasoningEffort:  getEnv("OPENAI_REASONING_EFFORT", ""),
+		AnthropicAPIKey:        getEnv("ANTHROPIC_API_KEY", ""),
+		AnthropicModel:         getEnv("ANTHROPIC_MODEL", "claude-3-5-sonnet-20241022"),
+		GeminiAPIKey:           getEnv("GEMINI_API_KEY", ""),
+		GeminiModel:            getEnv("GEMINI_MODEL", "gemini-1.5-pro"),
+		GeminiToolCallFormat:   getEnv("GEMINI_TOOL_CALL_FORMAT", "native"),
+		GleanAPIKey:            getEnv("GLEAN_API_KEY", ""),
+		GleanInstance:          getEnv("GLEAN_INSTANCE", ""),
+		GleanModel:             getEnv("GLEAN_MODEL", "glean-default"),
+		GleanToolCallFormat:    getEnv("GLEAN_TOOL_CALL_FORMAT", "tool_call_text"),
+		BedrockRegion:          getEnv("BEDROCK_REGION", os.Getenv("AWS_REGION")),
+		BedrockModel:           getEnv("BEDROCK_MODEL", "anthropic.claude-3-5-sonnet-20240620-v1:0"),
+		OllamaBaseURL:          getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+		OllamaModel:            getEnv("OLLAMA_MODEL", ""),
+		CohereAPIKey:           getEnv("COHERE_API_KEY", ""),
+		CohereModel:            getEnv("COHERE_MODEL", "command-r-plus-08-2024"),
+		MistralAPIKey:          getEnv("MISTRAL_API_KEY", ""),
+		MistralModel:           getEnv("MISTRAL_MODEL", "mistral-large-latest"),
+		EnabledProviders:       getEnvList("ENABLED_PROVIDERS", nil),
+		MCPServerURL:           getEnv("MCP_SERVER_URL", "http://localhost:3000"),
+		MCPClientName:          getEnv("MCP_CLIENT_NAME", "idp-cloudgenie-backend"),
+		MCPClientVersion:       getEnv("MCP_CLIENT_VERSION", "1.0.0"),
+		MCPClientCapabilities:  getEnvList("MCP_CLIENT_CAPABILITIES", nil),
+		MCPKeepAlive:           getEnvDuration("MCP_KEEPALIVE", 0),
+		MCPCallTimeout:         getEnvDuration("MCP_CALL_TIMEOUT", 30*time.Second),
+		MCPConnectTimeout:      getEnvDuration("MCP_CONNECT_TIMEOUT", 10*time.Second),
+		MCPAllowedTools:        getEnvList("MCP_ALLOWED_TOOLS", nil),
+		MCPDeniedTools:         getEnvList("MCP_DENIED_TOOLS", nil),
+		MCPToolRefresh:         getEnvDuration("MCP_TOOL_REFRESH_INTERVAL", 5*time.Minute),
+		CloudGenieBackendURL:   getEnv("CLOUDGENIE_BACKEND_URL", "http://localhost:8080"),
+		AllowedOrigins:         getEnvList("ALLOWED_ORIGINS", []string{"*"}),
+		CredentialedOrigins:    getEnvList("CREDENTIALED_ORIGINS", nil),
+		ToolResultStripFields:  getEnvToolFields("TOOL_RESULT_STRIP_FIELDS"),
+		DefaultBlueprints:      getEnvMap("DEFAULT_BLUEPRINTS"),
+		MaxConcurrentChats:     getEnvInt("MAX_CONCURRENT_CHATS", 10),
+		ChatQueueTimeout:       getEnvDuration("CHAT_QUEUE_TIMEOUT", 10*time.Second),
+		ProviderRateLimitWait:  getEnvDuration("AI_RATE_LIMIT_WAIT", 5*time.Second),
+		ProviderRetryAttempts:  getEnvInt("AI_RETRY_MAX_ATTEMPTS", 3),
+		ProviderRetryBaseDelay: getEnvDuration("AI_RETRY_BASE_DELAY", 500*time.Millisecond),
+		HistoryTokenBudget:     getEnvInt("HISTORY_TOKEN_BUDGET", 32000),
+		ToolPromptTokenBudget:  getEnvInt("TOOL_PROMPT_TOKEN_BUDGET", 6000),
+		MaxToolIterations:      getEnvInt("MAX_TOOL_ITERATIONS", 5),
+		ToolCacheTTL:           getEnvDuration("TOOL_CACHE_TTL", 5*time.Minute),
+		BlueprintCacheTTL:      getEnvDuration("BLUEPRINT_CACHE_TTL", 60*time.Second),
+		MongoDBURI:             getEnv("MONGODB_URI", ""),
+		MongoDBDatabase:        getEnv("MONGODB_DATABASE", "cloudgenie"),
+		CacheableToolPrefixes:  getEnvList("CACHEABLE_TOOL_PREFIXES", []string{"get_", "list_", "describe_"}),
+		LogRedactPatterns:      getEnvList("LOG_REDACT_PATTERNS", logging.DefaultRedactPatterns),
+		LogFormat:              getEnv("LOG_FORMAT", "text"),
+		AdminToken:             getEnv("ADMIN_TOKEN", ""),
+		MaintenanceMode:        getEnvBool("MAINTENANCE_MODE", false),
+
+		BlueprintAllowedCategories: getEnvList("BLUEPRINT_ALLOWED_CATEGORIES", nil),
+		BlueprintDeniedCategories:  getEnvList("BLUEPRINT_DENIED_CATEGORIES", nil),
+
+		BlockDeprecatedBlueprints: getEnvBool("BLOCK_DEPRECATED_BLUEPRINTS", false),
+	}
+
+	if cfg.ProviderRetryAttempts < 1 {
+		recordInvalid("AI_RETRY_MAX_ATTEMPTS", os.Getenv("AI_RETRY_MAX_ATTEMPTS"), "attempt count (must be at least 1)")
+	}
+	if cfg.ProviderRetryBaseDelay < 0 {
+		recordInvalid("AI_RETRY_BASE_DELAY", os.Getenv("AI_RETRY_BASE_DELAY"), "delay (must not be negative)")
+	}
+
+	if len(invalidValues) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %s", strings.Join(invalidValues, "; "))
+	}
+
+	cfg.ProviderRateLimits = make(map[string]int)
+	for provider, value := range getEnvMap("AI_RATE_LIMITS") {
+		rpm, err := strconv.Atoi(value)
+		if err != nil || rpm <= 0 {
+			return nil, fmt.Errorf("AI_RATE_LIMITS: %s must be a positive requests-per-minute value, got %q", provider, value)
+		}
+		cfg.ProviderRateLimits[provider] = rpm
+	}
+
+	cfg.HistoryTokenBudgets = make(map[string]int)
+	for provider, value := range getEnvMap("HISTORY_TOKEN_BUDGETS") {
+		tokens, err := strconv.Atoi(value)
+		if err != nil || tokens <= 0 {
+			return nil, fmt.Errorf("HISTORY_TOKEN_BUDGETS: %s must be a positive token count, got %q", provider, value)
+		}
+		cfg.HistoryTokenBudgets[provider] = tokens
+	}
+
+	if cfg.MaxToolIterations <= 0 {
+		return nil, fmt.Errorf("MAX_TOOL_ITERATIONS must be greater than 0, got %d", cfg.MaxToolIterations)
+	}
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		return nil, fmt.Errorf("LOG_FORMAT must be text or json, got %q", cfg.LogFormat)
+	}
+	if cfg.MCPCallTimeout < 0 || cfg.MCPConnectTimeout < 0 {
+		return nil, fmt.Errorf("MCP_CALL_TIMEOUT and MCP_CONNECT_TIMEOUT must not be negative")
+	}
+	if cfg.ToolCacheTTL < 0 {
+		return nil, fmt.Errorf("TOOL_CACHE_TTL must not be negative, got %s", cfg.ToolCacheTTL)
+	}
+	if cfg.BlueprintCacheTTL < 0 {
+		return nil, fmt.Errorf("BLUEPRINT_CACHE_TTL must not be negative, got %s", cfg.BlueprintCacheTTL)
+	}
+
+	if len(cfg.EnabledProviders) > 0 && !cfg.ProviderEnabled(cfg.DefaultAIProvider) {
+		return nil, fmt.Errorf("DEFAULT_AI_PROVIDER %q is not in ENABLED_PROVIDERS (%s)", cfg.DefaultAIProvider, strings.Join(cfg.EnabledProviders, ","))
+	}
 
 	// Validate required fields based on AI provider
 	if cfg.DefaultAIProvider == "openai" && cfg.OpenAIAPIKey == "" {
@@ -70,18 +250,156 @@ func Load() (*Config, error) {
 	if cfg.DefaultAIProvider == "glean" && cfg.GleanAPIKey == "" {
 		return nil, fmt.Errorf("GLEAN_API_KEY is required when using glean provider")
 	}
+	if cfg.DefaultAIProvider == "bedrock" && cfg.BedrockRegion == "" {
+		return nil, fmt.Errorf("BEDROCK_REGION (or AWS_REGION) is required when using bedrock provider")
+	}
+	switch cfg.OpenAIReasoningEffort {
+	case "", "low", "medium", "high":
+	default:
+		return nil, fmt.Errorf("OPENAI_REASONING_EFFORT must be one of low, medium, high")
+	}
+	for _, origin := range cfg.CredentialedOrigins {
+		if origin == "*" {
+			return nil, fmt.Errorf("CREDENTIALED_ORIGINS must list explicit origins, not *")
+		}
+	}
+	if cfg.MaxConcurrentChats < 0 {
+		return nil, fmt.Errorf("MAX_CONCURRENT_CHATS must not be negative")
+	}
 	if cfg.MCPServerURL == "" {
 		return nil, fmt.Errorf("MCP_SERVER_URL is required")
 	}
 
+	cfg.settings = loadedSettings
 	return cfg, nil
 }
 
+// ProviderEnabled reports whether a provider may be used: it is in ENABLED_PROVIDERS, or the list is empty
+func (c *Config) ProviderEnabled(name string) bool {
+	if len(c.EnabledProviders) == 0 {
+		return true
+	}
+	for _, enabled := range c.EnabledProviders {
+		if strings.EqualFold(enabled, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // getEnv gets an environment variable with a fallback default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
+		record(key, value, true, defaultValue)
+		return defaultValue
+	}
+	record(key, value, false, "")
+	return value
+}
+
+// getEnvList gets a comma-separated environment variable as a list, trimming blanks
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		record(key, value, true, strings.Join(defaultValue, ","))
+		return defaultValue
+	}
+	record(key, value, false, "")
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvInt gets an integer environment variable, falling back to the default if unset.
+// Invalid values are reported by Load
+func getEnvInt(key string, defaultValue int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		record(key, raw, true, strconv.Itoa(defaultValue))
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		recordInvalid(key, raw, "integer")
+		record(key, raw, true, strconv.Itoa(defaultValue))
 		return defaultValue
 	}
+	record(key, raw, false, "")
 	return value
 }
+
+// getEnvDuration gets a duration environment variable (e.g. "30s"), falling back to the default if unset.
+// Invalid values are reported by Load
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		record(key, raw, true, defaultValue.String())
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		recordInvalid(key, raw, "duration")
+		record(key, raw, true, defaultValue.String())
+		return defaultValue
+	}
+	record(key, raw, false, "")
+	return value
+}
+
+// getEnvBool gets a boolean environment variable ("true", "1", "false", ...), falling back to the default if unset.
+// Invalid values are reported by Load
+func getEnvBool(key string, defaultValue bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		record(key, raw, true, strconv.FormatBool(defaultValue))
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		recordInvalid(key, raw, "boolean")
+		record(key, raw, true, strconv.FormatBool(defaultValue))
+		return defaultValue
+	}
+	record(key, raw, false, "")
+	return value
+}
+
+// getEnvToolFields parses "tool_a=field1|field2,tool_b=field3" into a map of tool name to fields
+func getEnvToolFields(key string) map[string][]string {
+	result := make(map[string][]string)
+	for _, entry := range getEnvList(key, nil) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		tool := strings.TrimSpace(parts[0])
+		for _, field := range strings.Split(parts[1], "|") {
+			if field = strings.TrimSpace(field); field != "" {
+				result[tool] = append(result[tool], field)
+			}
+		}
+	}
+	return result
+}
+
+// getEnvMap parses "key_a=value_a,key_b=value_b" into a map
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, entry := range getEnvList(key, nil) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		k, v := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DEFAULT_AI_PROVIDER", "ollama")
+	t.Setenv("MCP_SERVER_URL", "http://localhost:3000")
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"MAX_CONCURRENT_CHATS", "ten"},
+		{"TOOL_CACHE_TTL", "5"},
+		{"MAINTENANCE_MODE", "yes please"},
+		{"AI_RETRY_MAX_ATTEMPTS", "0"},
+		{"AI_RETRY_BASE_DELAY", "-1s"},
+		{"MAX_TOOL_ITERATIONS", "0"},
+		{"TOOL_CACHE_TTL", "-1m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv(tt.key, tt.value)
+
+			_, err := Load()
+			if err == nil {
+				t.Fatalf("Load() with %s=%q: expected an error", tt.key, tt.value)
+			}
+			if !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("error %q does not name %s", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestLoadUsesDefaultsWhenUnset(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("MAX_CONCURRENT_CHATS", "")
+	t.Setenv("TOOL_CACHE_TTL", "")
+	t.Setenv("MAX_TOOL_ITERATIONS", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxConcurrentChats != 10 {
+		t.Errorf("MaxConcurrentChats = %d, want 10", cfg.MaxConcurrentChats)
+	}
+	if cfg.ToolCacheTTL != 5*time.Minute {
+		t.Errorf("ToolCacheTTL = %s, want 5m", cfg.ToolCacheTTL)
+	}
+	if cfg.MaxToolIterations != 5 {
+		t.Errorf("MaxToolIterations = %d, want 5", cfg.MaxToolIterations)
+	}
+}
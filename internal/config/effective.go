@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Setting is one resolved configuration value and where it came from
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // "env", "file" (.env) or "default"
+}
+
+const redactedValue = "[REDACTED]"
+
+// secretKeySegments mark settings whose values are never shown
+var secretKeySegments = map[string]bool{"KEY": true, "TOKEN": true, "SECRET": true, "PASSWORD": true}
+
+// Settings read by the getEnv helpers during Load. Load runs once at startup, before any
+// goroutines read configuration, so these are not guarded
+var (
+	processEnv     map[string]bool // keys set in the process environment before .env was loaded
+	loadedSettings map[string]Setting
+	invalidValues  []string // "KEY=value: reason" for values that were set but could not be parsed
+)
+
+// startRecording snapshots the process environment so values later loaded from .env can
+// be told apart from real environment variables
+func startRecording() {
+	processEnv = make(map[string]bool)
+	for _, entry := range os.Environ() {
+		if key, _, ok := strings.Cut(entry, "="); ok {
+			processEnv[key] = true
+		}
+	}
+	loadedSettings = make(map[string]Setting)
+	invalidValues = nil
+}
+
+// recordInvalid notes that key was set to a value its getEnv helper could not parse
+func recordInvalid(key, raw, want string) {
+	invalidValues = append(invalidValues, fmt.Sprintf("%s=%q is not a valid %s", key, raw, want))
+}
+
+// record notes the value a getEnv helper resolved for key. raw is the environment value and
+// defaultValue what was used instead when raw was empty
+func record(key, raw string, usedDefault bool, defaultValue string) {
+	if loadedSettings == nil {
+		return
+	}
+	setting := Setting{Key: key, Value: raw, Source: "file"}
+	switch {
+	case usedDefault:
+		setting.Value, setting.Source = defaultValue, "default"
+	case processEnv[key]:
+		setting.Source = "env"
+	}
+	loadedSettings[key] = setting
+}
+
+// Effective returns every setting the configuration was loaded from, sorted by key, with
+// API keys, tokens, secrets and connection-string credentials redacted
+func (c *Config) Effective() []Setting {
+	settings := make([]Setting, 0, len(c.settings))
+	for _, setting := range c.settings {
+		setting.Value = redactSetting(setting.Key, setting.Value)
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+func redactSetting(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	for _, segment := range strings.Split(key, "_") {
+		if secretKeySegments[segment] {
+			return redactedValue
+		}
+	}
+	if strings.HasSuffix(key, "_URI") || strings.HasSuffix(key, "_URL") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
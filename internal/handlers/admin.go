@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/config"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetAdminConfig enables the admin endpoints for callers presenting token as a bearer
+// token, and sets the effective configuration they report. An empty token keeps them disabled
+func (h *Handler) SetAdminConfig(token string, settings []config.Setting) {
+	h.adminToken = token
+	h.adminSettings = make([]models.ConfigSetting, len(settings))
+	for i, setting := range settings {
+		h.adminSettings[i] = models.ConfigSetting{Key: setting.Key, Value: setting.Value, Source: setting.Source}
+	}
+}
+
+// adminAuth admits requests carrying the admin bearer token
+func (h *Handler) adminAuth(c *gin.Context) {
+	if h.adminToken == "" {
+		writeError(c, models.ErrorCodeForbidden, "admin endpoints are disabled (ADMIN_TOKEN is not set)")
+		c.Abort()
+		return
+	}
+
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		writeError(c, models.ErrorCodeUnauthorized, "a valid admin bearer token is required")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// AdminConfigHandler returns the effective configuration with secrets redacted
+func (h *Handler) AdminConfigHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, models.AdminConfigResponse{
+		Settings: h.adminSettings,
+	})
+}
+
+// AdminMaintenanceHandler reports whether maintenance mode is on
+func (h *Handler) AdminMaintenanceHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenanceResponse())
+}
+
+// AdminSetMaintenanceHandler turns maintenance mode on or off at runtime
+func (h *Handler) AdminSetMaintenanceHandler(c *gin.Context) {
+	var request models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		writeError(c, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	h.orchestration.SetMaintenanceMode(*request.Enabled)
+	log.Printf("[trace %s] Maintenance mode set to %t", traceID(c), *request.Enabled)
+	c.JSON(http.StatusOK, h.maintenanceResponse())
+}
+
+func (h *Handler) maintenanceResponse() models.MaintenanceResponse {
+	enabled, since := h.orchestration.MaintenanceMode()
+	response := models.MaintenanceResponse{Enabled: enabled}
+	if enabled {
+		response.Since = &since
+	}
+	return response
+}
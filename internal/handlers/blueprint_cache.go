@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DefaultBlueprintCacheTTL is how long the blueprint catalog is reused before the MCP
+// server's blueprints tool is called again
+const DefaultBlueprintCacheTTL = 60 * time.Second
+
+// BlueprintCache holds the last decoded result of the blueprints tool for a fixed TTL, so
+// the blueprint endpoints don't call the MCP server on every request. It is safe for
+// concurrent use; a TTL of zero disables it
+type BlueprintCache struct {
+	mu        sync.RWMutex
+	ttl       time.Duration
+	data      interface{}
+	fetchedAt time.Time
+}
+
+// NewBlueprintCache creates a blueprint cache with the given TTL
+func NewBlueprintCache(ttl time.Duration) *BlueprintCache {
+	return &BlueprintCache{ttl: ttl}
+}
+
+// Get returns the cached catalog if one was stored within the TTL. Callers must not
+// modify the returned data, which is shared between requests
+func (c *BlueprintCache) Get() (interface{}, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.data == nil || time.Since(c.fetchedAt) > c.ttl {
+		return nil, false
+	}
+	return c.data, true
+}
+
+// Set stores a freshly fetched catalog
+func (c *BlueprintCache) Set(data interface{}) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data = data
+	c.fetchedAt = time.Now()
+}
+
+// Invalidate drops the cached catalog so the next Get misses
+func (c *BlueprintCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data = nil
+}
+
+// SetBlueprintCacheTTL sets how long the blueprint catalog is cached; zero disables caching
+func (s *OrchestrationService) SetBlueprintCacheTTL(ttl time.Duration) {
+	s.blueprintCache = NewBlueprintCache(ttl)
+}
+
+// InvalidateBlueprints forces the next blueprint request to re-read the catalog from the
+// MCP server
+func (s *OrchestrationService) InvalidateBlueprints() {
+	s.blueprintCache.Invalidate()
+}
+
+// rememberBlueprints caches a blueprints tool result the AI asked for, so creates in the same
+// chat can look up the blueprint they use without another MCP call. Results that aren't
+// JSON are ignored
+func (s *OrchestrationService) rememberBlueprints(formatted string) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(formatted), &data); err != nil {
+		return
+	}
+	s.blueprintCache.Set(data)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBlueprintCacheTTL(t *testing.T) {
+	cache := NewBlueprintCache(50 * time.Millisecond)
+	if _, ok := cache.Get(); ok {
+		t.Fatal("empty cache hit")
+	}
+
+	cache.Set([]interface{}{"postgres"})
+	if _, ok := cache.Get(); !ok {
+		t.Fatal("miss within the TTL")
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if _, ok := cache.Get(); ok {
+		t.Error("hit after the TTL expired")
+	}
+
+	cache.Set([]interface{}{"postgres"})
+	cache.Invalidate()
+	if _, ok := cache.Get(); ok {
+		t.Error("hit after Invalidate")
+	}
+
+	disabled := NewBlueprintCache(0)
+	disabled.Set([]interface{}{"postgres"})
+	if _, ok := disabled.Get(); ok {
+		t.Error("a zero TTL cache returned data")
+	}
+}
+
+func TestBlueprintCacheConcurrentUse(t *testing.T) {
+	cache := NewBlueprintCache(time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				switch j % 3 {
+				case 0:
+					cache.Set([]interface{}{i, j})
+				case 1:
+					cache.Get()
+				default:
+					cache.Invalidate()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestFetchBlueprintsReusesCatalogWithinTTL(t *testing.T) {
+	server := newTestMCPServer(t, testBlueprintsTool(`[{"name": "postgres"}]`))
+	service := newTestService(t, server, newFakeProvider("fake"), 5, time.Minute)
+	service.SetBlueprintCacheTTL(50 * time.Millisecond)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.MatchBlueprint(ctx, "postgres"); err != nil {
+			t.Fatalf("MatchBlueprint: %v", err)
+		}
+	}
+	if got := server.callCount("get_blueprints"); got != 1 {
+		t.Errorf("blueprints tool ran %d times within the TTL, want 1", got)
+	}
+
+	service.InvalidateBlueprints()
+	if _, err := service.MatchBlueprint(ctx, "postgres"); err != nil {
+		t.Fatalf("MatchBlueprint: %v", err)
+	}
+	if got := server.callCount("get_blueprints"); got != 2 {
+		t.Errorf("blueprints tool ran %d times after invalidation, want 2", got)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if _, err := service.MatchBlueprint(ctx, "postgres"); err != nil {
+		t.Fatalf("MatchBlueprint: %v", err)
+	}
+	if got := server.callCount("get_blueprints"); got != 3 {
+		t.Errorf("blueprints tool ran %d times after the TTL expired, want 3", got)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// blueprintDeprecation reads a blueprint's deprecated flag and message from its deprecated
+// and deprecationMessage fields or its blueprint-deprecated and deprecation-message
+// annotations. A deprecation message on its own also marks the blueprint deprecated
+func blueprintDeprecation(obj map[string]interface{}) (bool, string) {
+	message, _ := blueprintValue(obj, "deprecationMessage", "deprecation-message").(string)
+	message = strings.TrimSpace(message)
+
+	var deprecated bool
+	switch v := blueprintValue(obj, "deprecated", "blueprint-deprecated").(type) {
+	case bool:
+		deprecated = v
+	case string:
+		deprecated, _ = strconv.ParseBool(strings.TrimSpace(v))
+	}
+	return deprecated || message != "", message
+}
+
+// deprecationWarning describes a deprecated blueprint for the user, or returns "" if the
+// blueprint isn't deprecated
+func deprecationWarning(name string, deprecated bool, message string) string {
+	if !deprecated {
+		return ""
+	}
+	if message == "" {
+		return fmt.Sprintf("The %s blueprint is deprecated.", name)
+	}
+	return fmt.Sprintf("The %s blueprint is deprecated: %s", name, message)
+}
+
+// deprecatedBlueprintsNote lists the deprecated blueprints in a blueprints tool result, so
+// the model warns about them in capability answers. It returns "" when there are none
+func deprecatedBlueprintsNote(formatted string) string {
+	var data interface{}
+	if err := json.Unmarshal([]byte(formatted), &data); err != nil {
+		return ""
+	}
+
+	var warnings []string
+	for _, blueprint := range blueprintExports(data) {
+		if warning := deprecationWarning(blueprint.Name, blueprint.Deprecated, blueprint.DeprecationMessage); warning != "" {
+			warnings = append(warnings, "- "+warning)
+		}
+	}
+	if len(warnings) == 0 {
+		return ""
+	}
+	sort.Strings(warnings)
+	return "Deprecated blueprints (warn the user and suggest a supported blueprint when one of these fits):\n" +
+		strings.Join(warnings, "\n")
+}
+
+// annotateDeprecatedMatches copies the deprecation of each matched blueprint from the catalog
+func annotateDeprecatedMatches(matches []models.BlueprintMatch, exports []models.BlueprintExport) {
+	for i := range matches {
+		if blueprint := findBlueprint(exports, matches[i].Name); blueprint != nil {
+			matches[i].Deprecated = blueprint.Deprecated
+			matches[i].DeprecationMessage = blueprint.DeprecationMessage
+		}
+	}
+}
+
+// deprecatedBlueprintResult rejects a create against a deprecated blueprint when such creates are blocked
+func deprecatedBlueprintResult(blueprint *models.BlueprintExport) (string, ToolError) {
+	message := deprecationWarning(blueprint.Name, true, blueprint.DeprecationMessage) +
+		" New resources can't be created from it. Tell the user and suggest a supported blueprint."
+	return message, ToolError{Type: ToolErrorValidation, Code: "blueprint_deprecated", Retryable: false}
+}
+
+// SetBlockDeprecatedBlueprints controls whether creates against a deprecated blueprint are
+// rejected instead of only warned about
+func (s *OrchestrationService) SetBlockDeprecatedBlueprints(block bool) {
+	s.blockDeprecated = block
+}
+
+// lookupCreateBlueprint returns the blueprint a create call uses, like createBlueprint. When
+// deprecated blueprints are blocked, a catalog that isn't cached is fetched, so the block
+// can't be bypassed by an expired cache
+func (s *OrchestrationService) lookupCreateBlueprint(ctx context.Context, tool *mcp.Tool, args map[string]interface{}) *models.BlueprintExport {
+	if blueprint := s.createBlueprint(tool, args); blueprint != nil || !s.blockDeprecated {
+		return blueprint
+	}
+	name := createBlueprintName(tool, args)
+	if name == "" {
+		return nil
+	}
+	exports, err := s.ExportBlueprints(ctx)
+	if err != nil {
+		log.Printf("Could not read the blueprint catalog to check %s for deprecation: %v", name, err)
+		return nil
+	}
+	return findBlueprint(exports, name)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+const deprecatedBlueprints = `[
+	{"name": "postgres-v1", "annotations": {"blueprint-deprecated": "true", "deprecation-message": "Use postgres-v2."}},
+	{"name": "postgres-v2"},
+	{"name": "mysql", "deprecated": true}
+]`
+
+func TestBlueprintDeprecation(t *testing.T) {
+	tests := []struct {
+		name           string
+		blueprint      map[string]interface{}
+		wantDeprecated bool
+		wantMessage    string
+	}{
+		{"annotations", map[string]interface{}{"annotations": map[string]interface{}{"blueprint-deprecated": "true", "deprecation-message": "Use v2."}}, true, "Use v2."},
+		{"field", map[string]interface{}{"deprecated": true}, true, ""},
+		{"message alone", map[string]interface{}{"spec": map[string]interface{}{"deprecationMessage": "Retired in Q3."}}, true, "Retired in Q3."},
+		{"explicitly not deprecated", map[string]interface{}{"annotations": map[string]interface{}{"blueprint-deprecated": "false"}}, false, ""},
+		{"unannotated", map[string]interface{}{"name": "redis"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deprecated, message := blueprintDeprecation(tt.blueprint)
+			if deprecated != tt.wantDeprecated || message != tt.wantMessage {
+				t.Errorf("got (%v, %q), want (%v, %q)", deprecated, message, tt.wantDeprecated, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestMatchBlueprintMarksDeprecated(t *testing.T) {
+	server := newTestMCPServer(t, testBlueprintsTool(deprecatedBlueprints))
+	service := newTestService(t, server, newFakeProvider("fake"), 5, time.Minute)
+
+	matches, err := service.MatchBlueprint(context.Background(), "postgres")
+	if err != nil {
+		t.Fatalf("MatchBlueprint: %v", err)
+	}
+	deprecated := map[string]string{}
+	for _, match := range matches {
+		if match.Deprecated {
+			deprecated[match.Name] = match.DeprecationMessage
+		}
+	}
+	if len(matches) != 2 || len(deprecated) != 1 || deprecated["postgres-v1"] != "Use postgres-v2." {
+		t.Errorf("matches = %+v, want postgres-v1 marked deprecated", matches)
+	}
+}
+
+func TestProcessPromptWarnsAboutDeprecatedBlueprints(t *testing.T) {
+	server := newTestMCPServer(t)
+	server.addTool(testBlueprintsTool(deprecatedBlueprints))
+	provider := newFakeProvider("fake",
+		toolCallReply(ai.ToolCall{ID: "1", Name: "get_blueprints", Arguments: map[string]interface{}{}}),
+		toolCallReply(ai.ToolCall{ID: "2", Name: "create_resource", Arguments: map[string]interface{}{"name": "db", "blueprint": "postgres-v1"}}),
+		textReply("done"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+
+	resp := runPrompt(t, service, "create a postgres db")
+
+	calls := provider.chatCalls()
+	if listing := calls[1].prompt; !strings.Contains(listing, "The postgres-v1 blueprint is deprecated: Use postgres-v2.") {
+		t.Errorf("the blueprint listing doesn't point out the deprecated blueprint:\n%s", listing)
+	}
+	create := resp.ToolResults[1]
+	if create.IsError || create.DeprecationWarning != "The postgres-v1 blueprint is deprecated: Use postgres-v2." {
+		t.Errorf("create result = %+v, want a successful create with a deprecation warning", create)
+	}
+	if server.callCount("create_resource") != 1 {
+		t.Error("a warned create didn't run")
+	}
+}
+
+func TestProcessPromptBlocksDeprecatedBlueprints(t *testing.T) {
+	server := newTestMCPServer(t)
+	server.addTool(testBlueprintsTool(deprecatedBlueprints))
+	provider := newFakeProvider("fake",
+		// No listing first: the catalog is fetched to check the blueprint
+		toolCallReply(ai.ToolCall{ID: "1", Name: "create_resource", Arguments: map[string]interface{}{"name": "db", "blueprint": "mysql"}}),
+		textReply("done"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+	service.SetBlockDeprecatedBlueprints(true)
+
+	resp := runPrompt(t, service, "create a mysql db")
+
+	if result := resp.ToolResults[0]; !result.IsError || result.ErrorCode != "blueprint_deprecated" {
+		t.Errorf("create result = %+v, want a blueprint_deprecated error", result)
+	}
+	if server.callCount("create_resource") != 0 {
+		t.Error("a create against a deprecated blueprint ran")
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// blueprintProvisioningSeconds returns a blueprint's estimated provisioning time from its
+// estimatedProvisioningSeconds field or estimated-provisioning-seconds annotation, or 0 if it
+// has none. Annotations are strings, so numeric strings are accepted as well as numbers
+func blueprintProvisioningSeconds(obj map[string]interface{}) int {
+	switch v := blueprintValue(obj, "estimatedProvisioningSeconds", "estimated-provisioning-seconds").(type) {
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case string:
+		if seconds, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && seconds > 0 {
+			return seconds
+		}
+	}
+	return 0
+}
+
+// formatProvisioningEstimate renders an estimate in whole minutes, or seconds under a minute
+func formatProvisioningEstimate(seconds int) string {
+	if seconds < 60 {
+		return fmt.Sprintf("%d seconds", seconds)
+	}
+	minutes := (seconds + 59) / 60
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// provisioningEstimateNote tells the model how long a create from the blueprint usually
+// takes, so it can set the user's expectations. It returns "" for blueprints without an estimate
+func provisioningEstimateNote(blueprint *models.BlueprintExport) string {
+	if blueprint == nil || blueprint.EstimatedProvisioningSeconds <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("Provisioning from the %s blueprint usually takes about %s. Tell the user how long to expect.",
+		blueprint.Name, formatProvisioningEstimate(blueprint.EstimatedProvisioningSeconds))
+}
+
+// createBlueprintName returns the blueprint a create call names, or "" if the call isn't a
+// create or names no blueprint
+func createBlueprintName(tool *mcp.Tool, args map[string]interface{}) string {
+	if tool == nil || !strings.Contains(strings.ToLower(tool.Name), "create") {
+		return ""
+	}
+	param := blueprintParam(tool)
+	if param == "" {
+		return ""
+	}
+	name, _ := args[param].(string)
+	return name
+}
+
+// createBlueprint returns the catalog entry of the blueprint a create call names, from the
+// cached catalog. It returns nil when the call names no blueprint or the catalog isn't
+// cached: looking up an estimate is not worth an extra MCP call
+func (s *OrchestrationService) createBlueprint(tool *mcp.Tool, args map[string]interface{}) *models.BlueprintExport {
+	name := createBlueprintName(tool, args)
+	if name == "" {
+		return nil
+	}
+	data, ok := s.blueprintCache.Get()
+	if !ok {
+		return nil
+	}
+	return findBlueprint(blueprintExports(data), name)
+}
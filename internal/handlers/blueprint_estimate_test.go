@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+func TestBlueprintProvisioningSeconds(t *testing.T) {
+	tests := []struct {
+		name      string
+		blueprint map[string]interface{}
+		want      int
+	}{
+		{"field", map[string]interface{}{"estimatedProvisioningSeconds": float64(300)}, 300},
+		{"spec field", map[string]interface{}{"spec": map[string]interface{}{"estimatedProvisioningSeconds": float64(90)}}, 90},
+		{"XRD annotation", map[string]interface{}{"annotations": map[string]interface{}{"estimated-provisioning-seconds": " 600 "}}, 600},
+		{"unparseable annotation", map[string]interface{}{"annotations": map[string]interface{}{"estimated-provisioning-seconds": "ten minutes"}}, 0},
+		{"negative", map[string]interface{}{"estimatedProvisioningSeconds": float64(-5)}, 0},
+		{"no estimate", map[string]interface{}{"name": "postgres"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blueprintProvisioningSeconds(tt.blueprint); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatProvisioningEstimate(t *testing.T) {
+	for seconds, want := range map[int]string{45: "45 seconds", 60: "1 minute", 61: "2 minutes", 600: "10 minutes"} {
+		if got := formatProvisioningEstimate(seconds); got != want {
+			t.Errorf("formatProvisioningEstimate(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestProcessPromptReportsProvisioningEstimate(t *testing.T) {
+	server := newTestMCPServer(t)
+	server.addTool(testBlueprintsTool(`[{"name": "postgres", "annotations": {"estimated-provisioning-seconds": "600"}}, {"name": "redis"}]`))
+	provider := newFakeProvider("fake",
+		toolCallReply(ai.ToolCall{ID: "1", Name: "get_blueprints", Arguments: map[string]interface{}{}}),
+		toolCallReply(
+			ai.ToolCall{ID: "2", Name: "create_resource", Arguments: map[string]interface{}{"name": "orders-db", "blueprint": "postgres"}},
+			ai.ToolCall{ID: "3", Name: "create_resource", Arguments: map[string]interface{}{"name": "sessions", "blueprint": "redis"}},
+		),
+		textReply("done"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+
+	resp := runPrompt(t, service, "create a db and a cache")
+
+	if got := resp.ToolResults[1].EstimatedProvisioningSeconds; got != 600 {
+		t.Errorf("postgres create estimate = %d, want 600", got)
+	}
+	if got := resp.ToolResults[2].EstimatedProvisioningSeconds; got != 0 {
+		t.Errorf("redis create estimate = %d, want none", got)
+	}
+	// The catalog the model listed is reused, not fetched again for each create
+	if got := server.callCount("get_blueprints"); got != 1 {
+		t.Errorf("get_blueprints ran %d times, want 1", got)
+	}
+	if prompt := provider.chatCalls()[2].prompt; !strings.Contains(prompt, "usually takes about 10 minutes") {
+		t.Errorf("the model wasn't told the estimate:\n%s", prompt)
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// blueprintSchemaKeys are the fields a blueprint may keep its parameter schema under
+var blueprintSchemaKeys = []string{"schema", "parametersSchema", "parameters_schema", "inputSchema", "input_schema", "parameters"}
+
+// openAPIComponentName matches characters OpenAPI allows in component names
+var openAPIComponentName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// fetchBlueprints calls the MCP server's blueprints tool and returns its decoded result
+// without the blueprints hidden by the blueprint filter, reusing the cached result while it is fresh
+func (s *OrchestrationService) fetchBlueprints(ctx context.Context) (interface{}, error) {
+	if data, ok := s.blueprintCache.Get(); ok {
+		return data, nil
+	}
+
+	tool := blueprintsTool(s.currentTools())
+	if tool == nil {
+		return nil, fmt.Errorf("MCP server has no blueprints tool")
+	}
+
+	result, err := s.mcpClient.CallTool(ctx, tool.Name, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError {
+		return nil, fmt.Errorf("%s failed: %s", tool.Name, formatToolResult(result))
+	}
+
+	var data interface{}
+	if result.StructuredContent != nil {
+		data = result.StructuredContent
+	} else if err := json.Unmarshal([]byte(formatToolResult(result)), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s result: %w", tool.Name, err)
+	}
+	data = s.blueprintFilter.Filter(data)
+	s.blueprintCache.Set(data)
+	return data, nil
+}
+
+// ExportBlueprints returns the whole blueprint catalog with each blueprint's parameter schema
+func (s *OrchestrationService) ExportBlueprints(ctx context.Context) ([]models.BlueprintExport, error) {
+	data, err := s.fetchBlueprints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return blueprintExports(data), nil
+}
+
+// blueprintExports converts a decoded blueprints tool result to catalog entries, sorted by name
+func blueprintExports(data interface{}) []models.BlueprintExport {
+	var exports []models.BlueprintExport
+	for _, obj := range blueprintObjects(data) {
+		name := blueprintName(obj)
+		if name == "" {
+			continue
+		}
+		export := models.BlueprintExport{
+			Name:        name,
+			Version:     blueprintField(obj, "version", "blueprint-version"),
+			Description: blueprintField(obj, "description", "blueprint-description"),
+			Schema:      blueprintSchema(obj),
+			Blueprint:   obj,
+
+			EstimatedProvisioningSeconds: blueprintProvisioningSeconds(obj),
+		}
+		export.Deprecated, export.DeprecationMessage = blueprintDeprecation(obj)
+		exports = append(exports, export)
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].Name < exports[j].Name })
+	return exports
+}
+
+// BlueprintsOpenAPI renders exported blueprints as an OpenAPI document whose
+// components.schemas hold one parameter schema per blueprint
+func BlueprintsOpenAPI(exports []models.BlueprintExport) map[string]interface{} {
+	schemas := make(map[string]interface{}, len(exports))
+	for _, export := range exports {
+		schema := make(map[string]interface{}, len(export.Schema)+2)
+		for k, v := range export.Schema {
+			schema[k] = v
+		}
+		if _, ok := schema["description"]; !ok && export.Description != "" {
+			schema["description"] = export.Description
+		}
+		if export.Version != "" {
+			schema["x-blueprint-version"] = export.Version
+		}
+		schemas[openAPIComponentName.ReplaceAllString(export.Name, "_")] = schema
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   "CloudGenie blueprint catalog",
+			"version": "1.0.0",
+		},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+}
+
+// blueprintObjects extracts blueprint objects from a blueprints tool result, accepting a
+// bare array or an object wrapping one, like blueprintNames
+func blueprintObjects(data interface{}) []map[string]interface{} {
+	var objects []map[string]interface{}
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				objects = append(objects, obj)
+			}
+		}
+	case map[string]interface{}:
+		for _, field := range v {
+			if list, ok := field.([]interface{}); ok {
+				objects = append(objects, blueprintObjects(list)...)
+			}
+		}
+	}
+	return objects
+}
+
+// blueprintName returns a blueprint's name, preferring the blueprint-name label
+func blueprintName(obj map[string]interface{}) string {
+	if names := blueprintNames([]interface{}{obj}); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// blueprintField returns a string field from the blueprint, its spec, or its labels
+func blueprintField(obj map[string]interface{}, field, label string) string {
+	if v, ok := obj[field].(string); ok && v != "" {
+		return v
+	}
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		if v, ok := spec[field].(string); ok && v != "" {
+			return v
+		}
+	}
+	if labels, ok := obj["labels"].(map[string]interface{}); ok {
+		if v, ok := labels[label].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// blueprintValue returns a field of any type from the blueprint or its spec, or else the
+// named annotation or label, or nil
+func blueprintValue(obj map[string]interface{}, field, annotation string) interface{} {
+	if v, ok := obj[field]; ok && v != nil {
+		return v
+	}
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		if v, ok := spec[field]; ok && v != nil {
+			return v
+		}
+	}
+	for _, key := range []string{"annotations", "labels"} {
+		if values, ok := obj[key].(map[string]interface{}); ok {
+			if v, ok := values[annotation]; ok && v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// blueprintSchema finds a blueprint's parameter schema, on the blueprint itself or its spec.
+// A JSON Schema object is returned as is; a list of parameter definitions
+// ({name, type, description, default, enum, required}) is converted to one.
+// Blueprints without parameters get an empty object schema
+func blueprintSchema(obj map[string]interface{}) map[string]interface{} {
+	sources := []map[string]interface{}{obj}
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		sources = append(sources, spec)
+	}
+
+	for _, source := range sources {
+		for _, key := range blueprintSchemaKeys {
+			switch v := source[key].(type) {
+			case map[string]interface{}:
+				if _, ok := v["properties"]; ok {
+					return v
+				}
+				if _, ok := v["type"]; ok {
+					return v
+				}
+			case []interface{}:
+				if schema := paramListSchema(v); schema != nil {
+					return schema
+				}
+			}
+		}
+	}
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+// paramListSchema converts a list of parameter definitions to a JSON Schema object
+func paramListSchema(params []interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []interface{}
+	for _, item := range params {
+		param, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, ok := param["name"].(string)
+		if !ok || name == "" {
+			return nil
+		}
+
+		property := map[string]interface{}{"type": "string"}
+		for _, field := range []string{"type", "description", "default", "enum", "format", "minimum", "maximum", "pattern"} {
+			if v, ok := param[field]; ok {
+				property[field] = v
+			}
+		}
+		properties[name] = property
+		if isRequired, ok := param["required"].(bool); ok && isRequired {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
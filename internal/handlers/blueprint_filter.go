@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// BlueprintFilter hides internal blueprints by category or group. An empty allowlist allows
+// every blueprint; a blueprint whose category or group is denied is never exposed, even if
+// it is also allowlisted. With an allowlist, blueprints without a category or group are hidden
+type BlueprintFilter struct {
+	allowed map[string]bool
+	denied  map[string]bool
+}
+
+// NewBlueprintFilter creates a filter from allowed and denied categories or groups, compared
+// case-insensitively
+func NewBlueprintFilter(allowed, denied []string) *BlueprintFilter {
+	f := &BlueprintFilter{
+		allowed: make(map[string]bool, len(allowed)),
+		denied:  make(map[string]bool, len(denied)),
+	}
+	for _, category := range allowed {
+		f.allowed[strings.ToLower(category)] = true
+	}
+	for _, category := range denied {
+		f.denied[strings.ToLower(category)] = true
+	}
+	return f
+}
+
+// blueprintCategories returns a blueprint's category and group, from the blueprint, its spec
+// or its labels, lowercased
+func blueprintCategories(obj map[string]interface{}) []string {
+	var categories []string
+	for _, field := range [][2]string{{"category", "blueprint-category"}, {"group", "blueprint-group"}} {
+		if v := blueprintField(obj, field[0], field[1]); v != "" {
+			categories = append(categories, strings.ToLower(v))
+		}
+	}
+	return categories
+}
+
+// Allows reports whether a blueprint may be exposed. A nil or empty filter allows everything
+func (f *BlueprintFilter) Allows(obj map[string]interface{}) bool {
+	if f == nil || (len(f.allowed) == 0 && len(f.denied) == 0) {
+		return true
+	}
+
+	categories := blueprintCategories(obj)
+	for _, category := range categories {
+		if f.denied[category] {
+			return false
+		}
+	}
+	if len(f.allowed) == 0 {
+		return true
+	}
+	for _, category := range categories {
+		if f.allowed[category] {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a copy of a blueprints tool result without the blueprints the filter hides.
+// It accepts a bare array or an object wrapping one, like blueprintObjects, and leaves the
+// input unchanged
+func (f *BlueprintFilter) Filter(data interface{}) interface{} {
+	if f == nil || (len(f.allowed) == 0 && len(f.denied) == 0) {
+		return data
+	}
+
+	switch v := data.(type) {
+	case []interface{}:
+		kept := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok && !f.Allows(obj) {
+				continue
+			}
+			kept = append(kept, item)
+		}
+		if hidden := len(v) - len(kept); hidden > 0 {
+			log.Printf("Blueprint filter hides %d of %d blueprints", hidden, len(v))
+		}
+		return kept
+	case map[string]interface{}:
+		filtered := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if list, ok := field.([]interface{}); ok {
+				filtered[key] = f.Filter(list)
+			} else {
+				filtered[key] = field
+			}
+		}
+		return filtered
+	default:
+		return data
+	}
+}
+
+// FilterResult filters a formatted blueprints tool result before the model sees it.
+// Results that aren't JSON are returned unchanged
+func (f *BlueprintFilter) FilterResult(formatted string) string {
+	if f == nil || (len(f.allowed) == 0 && len(f.denied) == 0) {
+		return formatted
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(formatted), &data); err != nil {
+		return formatted
+	}
+	filtered, err := json.Marshal(f.Filter(data))
+	if err != nil {
+		return formatted
+	}
+	return string(filtered)
+}
+
+// SetBlueprintFilter restricts the blueprints exposed by the blueprint endpoints and in the
+// blueprints tool results the AI sees
+func (s *OrchestrationService) SetBlueprintFilter(filter *BlueprintFilter) {
+	s.blueprintFilter = filter
+	s.blueprintCache.Invalidate()
+}
+
+// processToolResult formats an MCP tool result for the model, running the tool's result
+// hook and, for the blueprints tool, hiding filtered blueprints, caching the catalog and
+// pointing out deprecated blueprints
+func (s *OrchestrationService) processToolResult(toolName string, args map[string]interface{}, result *mcp.CallToolResult) string {
+	formatted := s.resultHooks.Apply(toolName, result, formatToolResult(result))
+	if isBlueprintsToolName(toolName) {
+		formatted = s.blueprintFilter.FilterResult(formatted)
+		// Arguments may page or narrow the list, so only a plain listing is the whole catalog
+		if len(args) == 0 && !result.IsError {
+			s.rememberBlueprints(formatted)
+		}
+		if note := deprecatedBlueprintsNote(formatted); note != "" {
+			formatted += "\n\n" + note
+		}
+	}
+	return formatted
+}
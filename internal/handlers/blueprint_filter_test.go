@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+// testBlueprints covers a category field, a spec group, a category label and no category
+const testBlueprints = `[
+	{"name": "postgres", "category": "Database"},
+	{"name": "redis", "spec": {"group": "cache"}},
+	{"name": "billing-internal", "category": "database", "labels": {"blueprint-group": "internal"}},
+	{"name": "debug-pod", "labels": {"blueprint-category": "Internal"}},
+	{"name": "plain"}
+]`
+
+func filteredNames(t *testing.T, filter *BlueprintFilter, data interface{}) []string {
+	t.Helper()
+	names := blueprintNames(filter.Filter(data))
+	sort.Strings(names)
+	return names
+}
+
+func TestBlueprintFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		denied  []string
+		want    []string
+	}{
+		{"no filter", nil, nil, []string{"billing-internal", "debug-pod", "plain", "postgres", "redis"}},
+		{"deny", nil, []string{"internal"}, []string{"plain", "postgres", "redis"}},
+		{"allow", []string{"database", "CACHE"}, nil, []string{"billing-internal", "postgres", "redis"}},
+		{"deny wins over allow", []string{"database"}, []string{"Internal"}, []string{"postgres"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(testBlueprints), &data); err != nil {
+				t.Fatal(err)
+			}
+			filter := NewBlueprintFilter(tt.allowed, tt.denied)
+
+			if got := filteredNames(t, filter, data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("array: got %v, want %v", got, tt.want)
+			}
+			if got := filteredNames(t, filter, map[string]interface{}{"blueprints": data}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapped: got %v, want %v", got, tt.want)
+			}
+			if got := len(data.([]interface{})); got != 5 {
+				t.Errorf("Filter modified its input: %d blueprints left, want 5", got)
+			}
+		})
+	}
+}
+
+func TestBlueprintFilterResultLeavesNonJSON(t *testing.T) {
+	filter := NewBlueprintFilter(nil, []string{"internal"})
+	if got := filter.FilterResult("no blueprints found"); got != "no blueprints found" {
+		t.Errorf("FilterResult changed a text result to %q", got)
+	}
+	var nilFilter *BlueprintFilter
+	if got := nilFilter.FilterResult(testBlueprints); got != testBlueprints {
+		t.Errorf("nil filter changed the result")
+	}
+}
+
+func TestBlueprintFilterHidesBlueprintsFromAPIAndAI(t *testing.T) {
+	server := newTestMCPServer(t, testBlueprintsTool(testBlueprints))
+	provider := newFakeProvider("fake",
+		toolCallReply(ai.ToolCall{ID: "1", Name: "get_blueprints", Arguments: map[string]interface{}{}}),
+		textReply("done"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+	service.SetBlueprintFilter(NewBlueprintFilter(nil, []string{"internal"}))
+
+	matches, err := service.MatchBlueprint(context.Background(), "debug-pod")
+	if err != nil {
+		t.Fatalf("MatchBlueprint: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("MatchBlueprint found hidden blueprint: %v", matches)
+	}
+
+	resp := runPrompt(t, service, "which blueprints exist?")
+	if len(resp.ToolResults) != 1 {
+		t.Fatalf("got %d tool results, want 1", len(resp.ToolResults))
+	}
+	content := resp.ToolResults[0].Content
+	for _, hidden := range []string{"billing-internal", "debug-pod"} {
+		if strings.Contains(content, hidden) {
+			t.Errorf("tool result sent to the AI contains hidden blueprint %s: %s", hidden, content)
+		}
+	}
+	if !strings.Contains(content, "postgres") {
+		t.Errorf("tool result lost allowed blueprint postgres: %s", content)
+	}
+}
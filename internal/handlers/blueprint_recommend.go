@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// Recommendation limits for POST /blueprints/recommend
+const (
+	DefaultBlueprintRecommendations = 5
+	MaxBlueprintRecommendations     = 20
+)
+
+// Weights of the ways a description term can match a blueprint. A blueprint's score is the
+// average over the description's terms of the strongest match each one found
+const (
+	weightNameMatch           = 1.0
+	weightNameSynonymMatch    = 0.8
+	weightDescriptionMatch    = 0.6
+	weightDescriptionSynonym  = 0.5
+	minTermLengthForSubstring = 3
+)
+
+// recommendStopWords are dropped from descriptions before matching
+var recommendStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "be": true, "can": true, "create": true,
+	"deploy": true, "for": true, "get": true, "have": true, "how": true, "i": true, "in": true,
+	"is": true, "it": true, "like": true, "me": true, "my": true, "need": true, "new": true,
+	"of": true, "on": true, "or": true, "our": true, "please": true, "provision": true,
+	"set": true, "setup": true, "some": true, "that": true, "the": true, "this": true,
+	"to": true, "up": true, "want": true, "we": true, "what": true, "which": true,
+	"with": true, "would": true, "you": true,
+}
+
+// RecommendBlueprints fetches the blueprint catalog and ranks it against a natural-language
+// description (see RecommendBlueprints)
+func (s *OrchestrationService) RecommendBlueprints(ctx context.Context, description string, limit int) ([]models.BlueprintRecommendation, error) {
+	exports, err := s.ExportBlueprints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return RecommendBlueprints(description, exports, limit), nil
+}
+
+// RecommendBlueprints keyword-matches a description against blueprint names and descriptions
+// and returns up to limit blueprints with a score in (0, 1], best first. Name matches count
+// more than description matches, and the synonyms used by MatchBlueprint (db -> postgres)
+// count slightly less than the word itself. Blueprints matching no term are left out
+func RecommendBlueprints(description string, blueprints []models.BlueprintExport, limit int) []models.BlueprintRecommendation {
+	terms := recommendTerms(description)
+	if len(terms) == 0 {
+		return nil
+	}
+	if limit <= 0 {
+		limit = DefaultBlueprintRecommendations
+	}
+
+	var recommendations []models.BlueprintRecommendation
+	for _, blueprint := range blueprints {
+		name := normalizeBlueprintName(blueprint.Name)
+		words := make(map[string]bool)
+		for _, word := range splitWords(blueprint.Description) {
+			words[word] = true
+		}
+
+		var total float64
+		var matched []string
+		for _, term := range terms {
+			if weight := termWeight(term, name, words); weight > 0 {
+				total += weight
+				matched = append(matched, term)
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		recommendations = append(recommendations, models.BlueprintRecommendation{
+			Name:         blueprint.Name,
+			Version:      blueprint.Version,
+			Description:  blueprint.Description,
+			Score:        math.Round(total/float64(len(terms))*100) / 100,
+			MatchedTerms: matched,
+
+			Deprecated:         blueprint.Deprecated,
+			DeprecationMessage: blueprint.DeprecationMessage,
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].Name < recommendations[j].Name
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations
+}
+
+// termWeight returns the strongest way term matches a blueprint's normalized name or the
+// words of its description, or 0 if it matches neither
+func termWeight(term, name string, words map[string]bool) float64 {
+	switch {
+	case name == term || (len(term) >= minTermLengthForSubstring && strings.Contains(name, term)):
+		return weightNameMatch
+	case hasAlias(term, func(alias string) bool { return strings.Contains(name, alias) }):
+		return weightNameSynonymMatch
+	case hasWord(words, term):
+		return weightDescriptionMatch
+	case hasAlias(term, func(alias string) bool { return hasWord(words, alias) }):
+		return weightDescriptionSynonym
+	}
+	return 0
+}
+
+// hasAlias reports whether any synonym of term satisfies match
+func hasAlias(term string, match func(alias string) bool) bool {
+	for _, alias := range blueprintSynonyms[term] {
+		if match(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWord reports whether words holds term or its singular/plural form
+func hasWord(words map[string]bool, term string) bool {
+	return words[term] || words[term+"s"] || words[strings.TrimSuffix(term, "s")]
+}
+
+// recommendTerms returns the distinct, normalized words of a description that are worth
+// matching, in order
+func recommendTerms(description string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range splitWords(description) {
+		if seen[word] || recommendStopWords[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// splitWords lowercases text and splits it into words of letters and digits, dropping
+// single characters
+func splitWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, field := range fields {
+		if len(field) > 1 {
+			words = append(words, field)
+		}
+	}
+	return words
+}
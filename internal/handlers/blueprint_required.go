@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// ErrBlueprintNotFound is returned when a named blueprint is not in the MCP server's catalog
+var ErrBlueprintNotFound = errors.New("blueprint not found")
+
+// BlueprintRequiredFields returns the required parameters of the named blueprint, sorted by
+// name. The name is matched exactly first, then after normalization (see normalizeBlueprintName)
+func (s *OrchestrationService) BlueprintRequiredFields(ctx context.Context, name string) (*models.BlueprintRequiredResponse, error) {
+	exports, err := s.ExportBlueprints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blueprint := findBlueprint(exports, name)
+	if blueprint == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlueprintNotFound, name)
+	}
+
+	fields := []models.BlueprintRequiredField{}
+	for _, param := range parseToolParameters(blueprint.Schema) {
+		if param.Required {
+			fields = append(fields, models.BlueprintRequiredField{
+				Name:        param.Name,
+				Type:        param.Type,
+				Description: param.Description,
+			})
+		}
+	}
+	return &models.BlueprintRequiredResponse{
+		Blueprint: blueprint.Name,
+		Count:     len(fields),
+		Required:  fields,
+	}, nil
+}
+
+// findBlueprint returns the blueprint called name, or nil
+func findBlueprint(exports []models.BlueprintExport, name string) *models.BlueprintExport {
+	for i := range exports {
+		if exports[i].Name == name {
+			return &exports[i]
+		}
+	}
+	normalized := normalizeBlueprintName(name)
+	for i := range exports {
+		if normalizeBlueprintName(exports[i].Name) == normalized {
+			return &exports[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// blueprintParam returns the name of the tool's blueprint argument, or "" if it has none
+func blueprintParam(tool *mcp.Tool) string {
+	schema := toolSchema(tool)
+	if schema == nil {
+		return ""
+	}
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		if strings.Contains(strings.ToLower(name), "blueprint") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// resolveDefaultBlueprint fills in a missing blueprint argument on a create call from the
+// configured keyword mapping. It returns a note describing the default that was applied,
+// or an error listing the candidates when the arguments match several keywords.
+func resolveDefaultBlueprint(tool *mcp.Tool, args map[string]interface{}, defaults map[string]string) (string, error) {
+	if tool == nil || len(defaults) == 0 || !strings.Contains(strings.ToLower(tool.Name), "create") {
+		return "", nil
+	}
+
+	param := blueprintParam(tool)
+	if param == "" {
+		return "", nil
+	}
+	if value, ok := args[param].(string); ok && value != "" {
+		return "", nil
+	}
+
+	// Look for resource-type keywords in the other string arguments (name, type, description...)
+	matched := make(map[string]string)
+	for key, value := range args {
+		text, ok := value.(string)
+		if !ok || key == param {
+			continue
+		}
+		text = normalizeBlueprintName(text)
+		for keyword, blueprint := range defaults {
+			if strings.Contains(text, normalizeBlueprintName(keyword)) {
+				matched[blueprint] = keyword
+			}
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return "", nil
+	case 1:
+		for blueprint, keyword := range matched {
+			args[param] = blueprint
+			return fmt.Sprintf("No blueprint was specified, so the default blueprint %q for %q was used. Confirm this choice with the user.", blueprint, keyword), nil
+		}
+	}
+
+	candidates := make([]string, 0, len(matched))
+	for blueprint, keyword := range matched {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", blueprint, keyword))
+	}
+	sort.Strings(candidates)
+	return "", fmt.Errorf("no blueprint was specified and several defaults match: %s. Ask the user which blueprint to use", strings.Join(candidates, ", "))
+}
+
+// blueprintSynonyms maps common service names to the blueprint names that provide them.
+// Keys and values are normalized (see normalizeBlueprintName)
+var blueprintSynonyms = map[string][]string{
+	"db":           {"database", "postgres", "postgresql", "mysql", "mariadb", "mongodb"},
+	"database":     {"db", "postgres", "postgresql", "mysql", "mariadb", "mongodb"},
+	"sql":          {"postgres", "postgresql", "mysql", "mariadb"},
+	"postgres":     {"postgresql"},
+	"postgresql":   {"postgres"},
+	"cache":        {"redis", "memcached"},
+	"queue":        {"rabbitmq", "kafka", "sqs"},
+	"messaging":    {"rabbitmq", "kafka", "sqs"},
+	"vm":           {"virtualmachine", "server", "compute", "ec2"},
+	"server":       {"vm", "virtualmachine", "webserver", "compute", "ec2"},
+	"storage":      {"bucket", "s3", "objectstorage"},
+	"bucket":       {"storage", "s3", "objectstorage"},
+	"k8s":          {"kubernetes", "eks", "gke", "aks"},
+	"kubernetes":   {"k8s", "eks", "gke", "aks"},
+	"loadbalancer": {"lb", "alb", "elb", "nlb"},
+	"lb":           {"loadbalancer", "alb", "elb", "nlb"},
+}
+
+// normalizeBlueprintName lowercases a name and strips separators so that
+// "Postgres-DB", "postgres_db" and "postgres db" compare equal
+func normalizeBlueprintName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch r {
+		case '-', '_', ' ', '.', '/':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// MatchBlueprint returns the blueprints that provide the requested service, trying exact
+// matches first, then substring matches, then synonyms (db -> postgres). Names are
+// compared after normalization, and only the strongest kind of match found is returned
+func MatchBlueprint(query string, blueprints []string) []models.BlueprintMatch {
+	q := normalizeBlueprintName(query)
+	if q == "" {
+		return nil
+	}
+
+	var exact, substring, synonym []models.BlueprintMatch
+	for _, name := range blueprints {
+		n := normalizeBlueprintName(name)
+		if n == "" {
+			continue
+		}
+		switch {
+		case n == q:
+			exact = append(exact, models.BlueprintMatch{Name: name, MatchType: "exact"})
+		case strings.Contains(n, q) || strings.Contains(q, n):
+			substring = append(substring, models.BlueprintMatch{Name: name, MatchType: "substring"})
+		default:
+			for _, alias := range blueprintSynonyms[q] {
+				if strings.Contains(n, alias) {
+					synonym = append(synonym, models.BlueprintMatch{Name: name, MatchType: "synonym"})
+					break
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(exact) > 0:
+		return exact
+	case len(substring) > 0:
+		return substring
+	default:
+		return synonym
+	}
+}
+
+// blueprintsTool returns the MCP tool that lists blueprints, or nil if the server has none
+func blueprintsTool(tools []*mcp.Tool) *mcp.Tool {
+	for _, tool := range tools {
+		if isBlueprintsToolName(tool.Name) {
+			return tool
+		}
+	}
+	return nil
+}
+
+// isBlueprintsToolName reports whether a tool name looks like the tool that lists blueprints
+func isBlueprintsToolName(toolName string) bool {
+	name := strings.ToLower(toolName)
+	return strings.Contains(name, "blueprints") && (strings.Contains(name, "get") || strings.Contains(name, "list"))
+}
+
+// blueprintNames extracts blueprint names from a blueprints tool result, preferring the
+// blueprint-name label over the name field. It accepts a bare array or an object wrapping one
+func blueprintNames(data interface{}) []string {
+	var names []string
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if labels, ok := obj["labels"].(map[string]interface{}); ok {
+				if name, ok := labels["blueprint-name"].(string); ok && name != "" {
+					names = append(names, name)
+					continue
+				}
+			}
+			if name, ok := obj["name"].(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+	case map[string]interface{}:
+		for _, field := range v {
+			if list, ok := field.([]interface{}); ok {
+				names = append(names, blueprintNames(list)...)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// DefaultCacheableToolPrefixes mark read-only tools whose results may be cached
+var DefaultCacheableToolPrefixes = []string{"get_", "list_", "describe_"}
+
+// toolNamespaces are server prefixes stripped from tool names before matching cacheable
+// prefixes, so "cloudgenie_get_blueprints" matches "get_"
+var toolNamespaces = []string{"cloudgenie_"}
+
+// isCacheableTool reports whether a tool's results may be served from the result cache.
+// Tools the MCP server annotates as read-only are cacheable, as are tools whose name, after
+// any known namespace such as "cloudgenie_" is stripped, starts with one of prefixes. Destructive
+// tools never are, so a repeated create or delete always runs
+func isCacheableTool(tool *mcp.Tool, toolName string, prefixes []string) bool {
+	if isDestructiveTool(tool, toolName) {
+		return false
+	}
+	if tool != nil && tool.Annotations != nil && tool.Annotations.ReadOnlyHint {
+		return true
+	}
+
+	return hasToolPrefix(toolName, prefixes)
+}
+
+// hasToolPrefix reports whether the tool name, after any known namespace is stripped,
+// starts with one of prefixes
+func hasToolPrefix(toolName string, prefixes []string) bool {
+	name := unqualifiedToolName(toolName)
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// unqualifiedToolName lowercases a tool name and strips any known namespace, leaving
+// the verb-first name ("cloudgenie_Get_Blueprints" -> "get_blueprints")
+func unqualifiedToolName(toolName string) string {
+	name := strings.ToLower(toolName)
+	for _, namespace := range toolNamespaces {
+		name = strings.TrimPrefix(name, namespace)
+	}
+	return name
+}
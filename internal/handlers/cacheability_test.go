@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestIsCacheableTool(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      *sdkmcp.Tool
+		cacheable bool
+	}{
+		{"get_blueprints", nil, true},
+		{"cloudgenie_get_blueprints", nil, true},
+		{"list_resources", nil, true},
+		{"create_resource", nil, false},
+		{"cloudgenie_create_resource", nil, false},
+		{"reset_get_cache", nil, false},
+		{"cloudgenie_sync_list_state", nil, false},
+		{"resource_status", &sdkmcp.Tool{Annotations: &sdkmcp.ToolAnnotations{ReadOnlyHint: true}}, true},
+		{"delete_resource", &sdkmcp.Tool{Annotations: &sdkmcp.ToolAnnotations{ReadOnlyHint: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCacheableTool(tt.tool, tt.name, DefaultCacheableToolPrefixes); got != tt.cacheable {
+				t.Errorf("isCacheableTool(%q) = %v, want %v", tt.name, got, tt.cacheable)
+			}
+		})
+	}
+}
+
+func TestProcessPromptCachesOnlyReadOnlyTools(t *testing.T) {
+	server := newTestMCPServer(t)
+	provider := newFakeProvider("fake",
+		toolCallReply(
+			ai.ToolCall{ID: "1", Name: "get_blueprints", Arguments: map[string]interface{}{}},
+			ai.ToolCall{ID: "2", Name: "create_resource", Arguments: map[string]interface{}{"name": "db", "blueprint": "postgres"}},
+		),
+		textReply("first"),
+		toolCallReply(
+			ai.ToolCall{ID: "3", Name: "get_blueprints", Arguments: map[string]interface{}{}},
+			ai.ToolCall{ID: "4", Name: "create_resource", Arguments: map[string]interface{}{"name": "db", "blueprint": "postgres"}},
+		),
+		textReply("second"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+
+	for _, prompt := range []string{"create a db", "create it again"} {
+		if _, err := service.ProcessPrompt(context.Background(), &models.ChatRequest{Prompt: prompt}); err != nil {
+			t.Fatalf("ProcessPrompt(%q): %v", prompt, err)
+		}
+	}
+
+	if got := server.callCount("get_blueprints"); got != 1 {
+		t.Errorf("get_blueprints ran %d times, want 1 (second call served from cache)", got)
+	}
+	if got := server.callCount("create_resource"); got != 2 {
+		t.Errorf("create_resource ran %d times, want 2 (never cached)", got)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+const ConfirmationTTL = 5 * time.Minute // Pending destructive calls expire after 5 minutes
+
+// ErrInvalidConfirmationToken is returned when a confirmation token is unknown, expired or already used
+var ErrInvalidConfirmationToken = errors.New("invalid or expired confirmation token")
+
+// destructiveToolPrefixes mark tools whose calls must be confirmed by the user before running
+var destructiveToolPrefixes = []string{"delete_", "destroy_", "remove_"}
+
+// isDestructiveTool reports whether a tool performs a destructive action. The MCP server's
+// destructiveHint annotation decides when present; otherwise the tool name, after any namespace
+// such as "cloudgenie_" is stripped, must start with a destructive verb, so
+// "list_deleted_resources" is not destructive
+func isDestructiveTool(tool *mcp.Tool, toolName string) bool {
+	if tool != nil && tool.Annotations != nil && tool.Annotations.DestructiveHint != nil {
+		return *tool.Annotations.DestructiveHint
+	}
+	return hasToolPrefix(toolName, destructiveToolPrefixes)
+}
+
+// PendingToolCall is a destructive tool call held until the user confirms it
+type PendingToolCall struct {
+	Token     string
+	ToolName  string
+	Arguments map[string]interface{}
+	ExpiresAt time.Time
+}
+
+// ConfirmationStore holds pending destructive tool calls keyed by single-use token
+type ConfirmationStore struct {
+	pending map[string]*PendingToolCall
+	mu      sync.Mutex
+	ttl     time.Duration
+}
+
+// NewConfirmationStore creates a confirmation store with the specified TTL
+func NewConfirmationStore(ttl time.Duration) *ConfirmationStore {
+	return &ConfirmationStore{
+		pending: make(map[string]*PendingToolCall),
+		ttl:     ttl,
+	}
+}
+
+// Add registers a destructive tool call and returns its pending entry with a fresh token
+func (s *ConfirmationStore) Add(toolName string, args map[string]interface{}) (*PendingToolCall, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+
+	call := &PendingToolCall{
+		Token:     hex.EncodeToString(tokenBytes),
+		ToolName:  toolName,
+		Arguments: args,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired entries so abandoned confirmations don't accumulate
+	now := time.Now()
+	for token, p := range s.pending {
+		if now.After(p.ExpiresAt) {
+			delete(s.pending, token)
+		}
+	}
+	s.pending[call.Token] = call
+
+	return call, nil
+}
+
+// Consume returns the pending call for a token and removes it, so each token is used at most once
+func (s *ConfirmationStore) Consume(token string) (*PendingToolCall, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, exists := s.pending[token]
+	if !exists {
+		return nil, ErrInvalidConfirmationToken
+	}
+	delete(s.pending, token)
+
+	if time.Now().After(call.ExpiresAt) {
+		return nil, ErrInvalidConfirmationToken
+	}
+
+	return call, nil
+}
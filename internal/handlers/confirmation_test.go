@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestIsDestructiveTool(t *testing.T) {
+	destructive, safe := true, false
+	tests := []struct {
+		name        string
+		tool        *sdkmcp.Tool
+		destructive bool
+	}{
+		{"delete_resource", nil, true},
+		{"cloudgenie_delete_resource", nil, true},
+		{"destroy_cluster", nil, true},
+		{"remove_tag", nil, true},
+		{"list_deleted_resources", nil, false},
+		{"get_removal_status", nil, false},
+		{"cloudgenie_describe_destroy_policy", nil, false},
+		{"create_resource", nil, false},
+		{"purge_cache", &sdkmcp.Tool{Annotations: &sdkmcp.ToolAnnotations{DestructiveHint: &destructive}}, true},
+		{"delete_draft", &sdkmcp.Tool{Annotations: &sdkmcp.ToolAnnotations{DestructiveHint: &safe}}, false},
+		{"delete_resource", &sdkmcp.Tool{Annotations: &sdkmcp.ToolAnnotations{Title: "Delete"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDestructiveTool(tt.tool, tt.name); got != tt.destructive {
+				t.Errorf("isDestructiveTool(%q) = %v, want %v", tt.name, got, tt.destructive)
+			}
+		})
+	}
+}
+
+func TestProcessPromptHoldsOnlyDestructiveTools(t *testing.T) {
+	server := newTestMCPServer(t,
+		testTool{name: "list_deleted_resources"},
+		testTool{name: "delete_resource"},
+	)
+	provider := newFakeProvider("fake",
+		toolCallReply(
+			ai.ToolCall{ID: "1", Name: "list_deleted_resources", Arguments: map[string]interface{}{}},
+			ai.ToolCall{ID: "2", Name: "delete_resource", Arguments: map[string]interface{}{"name": "db"}},
+		),
+		textReply("done"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+
+	resp := runPrompt(t, service, "clean up")
+
+	if got := server.callCount("list_deleted_resources"); got != 1 {
+		t.Errorf("list_deleted_resources ran %d times, want 1", got)
+	}
+	if got := server.callCount("delete_resource"); got != 0 {
+		t.Errorf("delete_resource ran %d times, want 0 (held for confirmation)", got)
+	}
+	if len(resp.PendingConfirmations) != 1 || resp.PendingConfirmations[0].ToolName != "delete_resource" {
+		t.Errorf("PendingConfirmations = %+v, want one for delete_resource", resp.PendingConfirmations)
+	}
+}
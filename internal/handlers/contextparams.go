@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// fillContextParams pre-fills unspecified parameters of a create call from the request
+// context (e.g. region, project), matching names case- and separator-insensitively.
+// It returns a note listing the values that were filled in, or "" if none were
+func fillContextParams(tool *mcp.Tool, args map[string]interface{}, requestContext map[string]interface{}) string {
+	if tool == nil || len(requestContext) == 0 || !strings.Contains(strings.ToLower(tool.Name), "create") {
+		return ""
+	}
+
+	schema := toolSchema(tool)
+	if schema == nil {
+		return ""
+	}
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	contextValues := make(map[string]interface{}, len(requestContext))
+	for key, value := range requestContext {
+		contextValues[normalizeBlueprintName(key)] = value
+	}
+
+	var filled []string
+	for param := range properties {
+		if existing, ok := args[param]; ok && existing != nil && existing != "" {
+			continue
+		}
+		value, ok := contextValues[normalizeBlueprintName(param)]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+		args[param] = value
+		filled = append(filled, fmt.Sprintf("%s=%v", param, value))
+	}
+
+	if len(filled) == 0 {
+		return ""
+	}
+	sort.Strings(filled)
+	return fmt.Sprintf("These parameters were not specified and were filled in from the user's context: %s. Mention them to the user.", strings.Join(filled, ", "))
+}
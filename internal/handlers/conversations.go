@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+// ErrSessionsDisabled is returned when a request names a session but no conversation store is configured
+var ErrSessionsDisabled = errors.New("session_id requires conversation persistence (MONGODB_URI) to be configured")
+
+// ConversationRepository loads and saves the messages of a chat session
+type ConversationRepository interface {
+	GetConversation(ctx context.Context, sessionID string) ([]ai.Message, error)
+	AppendConversation(ctx context.Context, sessionID string, messages []ai.Message) error
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// memoryConversations is an in-memory ConversationRepository
+type memoryConversations struct {
+	mu       sync.Mutex
+	sessions map[string][]ai.Message
+}
+
+func newMemoryConversations() *memoryConversations {
+	return &memoryConversations{sessions: make(map[string][]ai.Message)}
+}
+
+func (r *memoryConversations) GetConversation(ctx context.Context, sessionID string) ([]ai.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ai.Message(nil), r.sessions[sessionID]...), nil
+}
+
+func (r *memoryConversations) AppendConversation(ctx context.Context, sessionID string, messages []ai.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[sessionID] = append(r.sessions[sessionID], messages...)
+	return nil
+}
+
+func TestProcessPromptRoundTripsSessionHistory(t *testing.T) {
+	provider := newFakeProvider("fake",
+		toolCallReply(ai.ToolCall{ID: "1", Name: "get_blueprints", Arguments: map[string]interface{}{}}),
+		textReply("There is one blueprint."),
+		textReply("It is postgres."),
+		textReply("Fresh start."),
+	)
+	service := newTestService(t, newTestMCPServer(t), provider, 5, time.Minute)
+	repo := newMemoryConversations()
+	service.SetConversationRepository(repo)
+
+	ctx := context.Background()
+	resp, err := service.ProcessPrompt(ctx, &models.ChatRequest{Prompt: "list blueprints", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("first ProcessPrompt: %v", err)
+	}
+	if resp.SessionID != "s1" {
+		t.Errorf("SessionID = %q, want s1", resp.SessionID)
+	}
+
+	// user prompt, tool call, tool results, final answer
+	saved, _ := repo.GetConversation(ctx, "s1")
+	if len(saved) != 4 {
+		t.Fatalf("saved %d messages, want 4: %+v", len(saved), saved)
+	}
+	if saved[0].Role != "user" || saved[0].Content != "list blueprints" {
+		t.Errorf("first saved message = %+v, want the user prompt", saved[0])
+	}
+	if len(saved[1].ToolCalls) != 1 || len(saved[2].ToolResults) != 1 || saved[3].Content != "There is one blueprint." {
+		t.Errorf("saved turns = %+v", saved)
+	}
+
+	if _, err := service.ProcessPrompt(ctx, &models.ChatRequest{Prompt: "which one?", SessionID: "s1"}); err != nil {
+		t.Fatalf("second ProcessPrompt: %v", err)
+	}
+	calls := provider.chatCalls()
+	if got := calls[2].history; len(got) < 4 || got[0].Content != "list blueprints" {
+		t.Errorf("second request history = %+v, want the first request's messages", got)
+	}
+	if saved, _ := repo.GetConversation(ctx, "s1"); len(saved) != 6 {
+		t.Errorf("saved %d messages after the second request, want 6", len(saved))
+	}
+
+	// Requests without a session stay stateless
+	if _, err := service.ProcessPrompt(ctx, &models.ChatRequest{Prompt: "hello"}); err != nil {
+		t.Fatalf("stateless ProcessPrompt: %v", err)
+	}
+	if got := provider.chatCalls()[3].history; len(got) != 0 {
+		t.Errorf("stateless request got %d history messages, want 0", len(got))
+	}
+}
+
+func TestProcessPromptRejectsSessionWithoutRepository(t *testing.T) {
+	service := newTestService(t, newTestMCPServer(t), newFakeProvider("fake"), 5, time.Minute)
+
+	_, err := service.ProcessPrompt(context.Background(), &models.ChatRequest{Prompt: "hi", SessionID: "s1"})
+	if !errors.Is(err, ErrSessionsDisabled) {
+		t.Errorf("ProcessPrompt error = %v, want ErrSessionsDisabled", err)
+	}
+}
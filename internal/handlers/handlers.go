@@ -1,20 +1,30 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/logging"
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
 	orchestration *OrchestrationService
+	chatLimiter   *ChatLimiter
+
+	adminToken    string                 // bearer token for admin endpoints; empty = disabled
+	adminSettings []models.ConfigSetting // effective configuration, already redacted
 }
 
-func NewHandler(orchestration *OrchestrationService) *Handler {
+func NewHandler(orchestration *OrchestrationService, chatLimiter *ChatLimiter) *Handler {
 	return &Handler{
 		orchestration: orchestration,
+		chatLimiter:   chatLimiter,
 	}
 }
 
@@ -22,36 +32,51 @@ func NewHandler(orchestration *OrchestrationService) *Handler {
 func (h *Handler) ChatHandler(c *gin.Context) {
 	var request models.ChatRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_request",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		writeError(c, models.ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
-	log.Printf("Received chat request: %s (provider: %s, model: %s)", 
-		request.Prompt, request.Provider, request.Model)
+	log.Printf("[trace %s] Received chat request: %s (provider: %s, model: %s)", traceID(c),
+		logging.RedactString(request.Prompt), request.Provider, request.Model)
+
+	// Limit concurrent orchestrations to protect provider quota
+	if !h.chatLimiter.Acquire(c.Request.Context()) {
+		retryAfter := h.chatLimiter.RetryAfter()
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		writeError(c, models.ErrorCodeTooManyRequests, fmt.Sprintf("Too many chat requests in progress, retry after %s", retryAfter))
+		return
+	}
+	defer h.chatLimiter.Release()
 
 	// Process the prompt through orchestration
 	response, err := h.orchestration.ProcessPrompt(c.Request.Context(), &request)
 	if err != nil {
-		log.Printf("Error processing prompt: %v", err)
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "processing_error",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		log.Printf("[trace %s] Error processing prompt: %v", traceID(c), err)
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+		}
+		// The provider's own error is logged above; the user gets a message they can act on
+		if errors.Is(err, ErrAIUnavailable) {
+			c.Header("Retry-After", strconv.Itoa(int(AIUnavailableRetryAfter.Seconds())))
+			writeError(c, models.ErrorCodeAIUnavailable, "The AI service is temporarily unavailable, please try again shortly")
+			return
+		}
+		writeError(c, errorCodeFor(err), err.Error())
 		return
 	}
 
+	response.TraceID = traceID(c)
 	c.JSON(http.StatusOK, response)
 }
 
 // HealthHandler checks the health of the service
 func (h *Handler) HealthHandler(c *gin.Context) {
 	services := h.orchestration.HealthCheck(c.Request.Context())
-	
+	chatStats := h.chatLimiter.Stats()
+	services["chat_in_flight"] = strconv.Itoa(chatStats["in_flight"])
+	services["chat_queued"] = strconv.Itoa(chatStats["queued"])
+
 	mcpReady := services["mcp_client"] == "connected"
 	status := "healthy"
 	if !mcpReady {
@@ -61,6 +86,7 @@ func (h *Handler) HealthHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, models.HealthResponse{
 		Status:         status,
 		MCPServerReady: mcpReady,
+		Maintenance:    h.orchestration.inMaintenance(),
 		Services:       services,
 	})
 }
@@ -73,6 +99,221 @@ func (h *Handler) ToolsHandler(c *gin.Context) {
 	})
 }
 
+// ToolsRefreshHandler re-reads the tool list from the MCP server and returns it
+func (h *Handler) ToolsRefreshHandler(c *gin.Context) {
+	changed, err := h.orchestration.RefreshTools(c.Request.Context())
+	if err != nil {
+		log.Printf("Error refreshing tools: %v", err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToolsRefreshResponse{
+		Changed: changed,
+		Tools:   h.orchestration.GetAvailableTools(),
+	})
+}
+
+// MCPResourcesHandler returns the resources published by the MCP server
+func (h *Handler) MCPResourcesHandler(c *gin.Context) {
+	resources, err := h.orchestration.ListMCPResources(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing MCP resources: %v", err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MCPResourcesResponse{
+		Resources: resources,
+	})
+}
+
+// BlueprintMatchHandler reports which blueprints provide the service named by the q query parameter
+func (h *Handler) BlueprintMatchHandler(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		writeError(c, models.ErrorCodeInvalidRequest, "q query parameter is required")
+		return
+	}
+
+	matches, err := h.orchestration.MatchBlueprint(c.Request.Context(), query)
+	if err != nil {
+		log.Printf("Error matching blueprints: %v", err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+	if matches == nil {
+		matches = []models.BlueprintMatch{}
+	}
+
+	c.JSON(http.StatusOK, models.BlueprintMatchResponse{
+		Query:   query,
+		Matched: len(matches) > 0,
+		Matches: matches,
+	})
+}
+
+// BlueprintRecommendHandler suggests blueprints for a natural-language description without
+// going through the AI
+func (h *Handler) BlueprintRecommendHandler(c *gin.Context) {
+	var request models.BlueprintRecommendRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		writeError(c, models.ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	recommendations, err := h.orchestration.RecommendBlueprints(c.Request.Context(), request.Description, request.Limit)
+	if err != nil {
+		log.Printf("Error recommending blueprints: %v", err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+	if recommendations == nil {
+		recommendations = []models.BlueprintRecommendation{}
+	}
+
+	c.JSON(http.StatusOK, models.BlueprintRecommendResponse{
+		Description:     request.Description,
+		Count:           len(recommendations),
+		Recommendations: recommendations,
+	})
+}
+
+// BlueprintRequiredHandler returns just the required parameter names and types of one
+// blueprint, e.g. so the assistant can list what it needs before a create
+func (h *Handler) BlueprintRequiredHandler(c *gin.Context) {
+	name := c.Param("name")
+	required, err := h.orchestration.BlueprintRequiredFields(c.Request.Context(), name)
+	if err != nil {
+		if errors.Is(err, ErrBlueprintNotFound) {
+			writeError(c, models.ErrorCodeBlueprintNotFound, err.Error())
+			return
+		}
+		log.Printf("Error getting required fields for blueprint %s: %v", name, err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, required)
+}
+
+// BlueprintExportHandler returns the whole blueprint catalog with parameter schemas, as JSON
+// or, with format=openapi, as an OpenAPI document of component schemas
+func (h *Handler) BlueprintExportHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "openapi" {
+		writeError(c, models.ErrorCodeInvalidRequest, fmt.Sprintf("unsupported format %q (use json or openapi)", format))
+		return
+	}
+
+	exports, err := h.orchestration.ExportBlueprints(c.Request.Context())
+	if err != nil {
+		log.Printf("Error exporting blueprints: %v", err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+	if exports == nil {
+		exports = []models.BlueprintExport{}
+	}
+
+	if format == "openapi" {
+		c.JSON(http.StatusOK, BlueprintsOpenAPI(exports))
+		return
+	}
+	c.JSON(http.StatusOK, models.BlueprintExportResponse{
+		Count:      len(exports),
+		Blueprints: exports,
+	})
+}
+
+// MCPReadResourceHandler returns the contents of the MCP resource named by the uri query parameter
+func (h *Handler) MCPReadResourceHandler(c *gin.Context) {
+	uri := c.Query("uri")
+	if uri == "" {
+		writeError(c, models.ErrorCodeInvalidRequest, "uri query parameter is required")
+		return
+	}
+
+	contents, err := h.orchestration.ReadMCPResource(c.Request.Context(), uri)
+	if err != nil {
+		log.Printf("Error reading MCP resource %s: %v", uri, err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MCPResourceContentsResponse{
+		Contents: contents,
+	})
+}
+
+// MCPPromptsHandler returns the prompt templates published by the MCP server
+func (h *Handler) MCPPromptsHandler(c *gin.Context) {
+	prompts, err := h.orchestration.ListMCPPrompts(c.Request.Context())
+	if err != nil {
+		log.Printf("Error listing MCP prompts: %v", err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MCPPromptsResponse{
+		Prompts: prompts,
+	})
+}
+
+// MCPGetPromptHandler renders an MCP prompt template with the arguments in the request body
+func (h *Handler) MCPGetPromptHandler(c *gin.Context) {
+	var request models.MCPPromptRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			writeError(c, models.ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	name := c.Param("name")
+	prompt, err := h.orchestration.GetMCPPrompt(c.Request.Context(), name, request.Arguments)
+	if err != nil {
+		log.Printf("Error getting MCP prompt %s: %v", name, err)
+		writeError(c, models.ErrorCodeMCPError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// writeError sends an ErrorResponse with the HTTP status mapped from its code
+func writeError(c *gin.Context, code models.ErrorCode, message string) {
+	status := code.HTTPStatus()
+	c.JSON(status, models.ErrorResponse{
+		Error:   code,
+		Message: message,
+		Code:    status,
+		TraceID: traceID(c),
+	})
+}
+
+// errorCodeFor classifies an orchestration error into an API error code
+func errorCodeFor(err error) models.ErrorCode {
+	switch {
+	case errors.Is(err, ErrInvalidConfirmationToken):
+		return models.ErrorCodeInvalidConfirmation
+	case errors.Is(err, ErrMaintenance):
+		return models.ErrorCodeMaintenance
+	case errors.Is(err, ErrSessionsDisabled):
+		return models.ErrorCodeInvalidRequest
+	case errors.Is(err, ErrProviderRateLimited):
+		return models.ErrorCodeTooManyRequests
+	case errors.Is(err, ErrProviderUnavailable):
+		return models.ErrorCodeProviderUnavailable
+	case errors.Is(err, ErrAIUnavailable):
+		return models.ErrorCodeAIUnavailable
+	case errors.Is(err, ErrAIProvider):
+		return models.ErrorCodeAIError
+	default:
+		return models.ErrorCodeProcessingError
+	}
+}
+
 // SetupRoutes configures all HTTP routes
 func SetupRoutes(router *gin.Engine, handler *Handler) {
 	// API v1 routes
@@ -80,12 +321,31 @@ func SetupRoutes(router *gin.Engine, handler *Handler) {
 	{
 		// Chat endpoint
 		v1.POST("/chat", handler.ChatHandler)
-		
+
 		// Health check
 		v1.GET("/health", handler.HealthHandler)
-		
+
 		// List available tools
 		v1.GET("/tools", handler.ToolsHandler)
+		v1.POST("/tools/refresh", handler.ToolsRefreshHandler)
+
+		// Blueprint matching for capability questions
+		v1.GET("/blueprints/match", handler.BlueprintMatchHandler)
+		v1.GET("/blueprints/export", handler.BlueprintExportHandler)
+		v1.POST("/blueprints/recommend", handler.BlueprintRecommendHandler)
+		v1.GET("/blueprints/:name/required", handler.BlueprintRequiredHandler)
+
+		// MCP resources and prompts
+		v1.GET("/mcp/resources", handler.MCPResourcesHandler)
+		v1.GET("/mcp/resources/read", handler.MCPReadResourceHandler)
+		v1.GET("/mcp/prompts", handler.MCPPromptsHandler)
+		v1.POST("/mcp/prompts/:name", handler.MCPGetPromptHandler)
+
+		// Operator endpoints, guarded by ADMIN_TOKEN
+		admin := v1.Group("/admin", handler.adminAuth)
+		admin.GET("/config", handler.AdminConfigHandler)
+		admin.GET("/maintenance", handler.AdminMaintenanceHandler)
+		admin.PUT("/maintenance", handler.AdminSetMaintenanceHandler)
 	}
 
 	// Root health check
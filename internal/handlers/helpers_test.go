@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// testTool is a tool served by the test MCP server. handler defaults to returning "<name> ok"
+type testTool struct {
+	name    string
+	schema  map[string]any
+	handler func(args map[string]any) (*sdkmcp.CallToolResult, error)
+}
+
+// testMCPServer is a streamable HTTP MCP server that counts calls per tool
+type testMCPServer struct {
+	*httptest.Server
+	server *sdkmcp.Server
+
+	mu    sync.Mutex
+	calls map[string]int
+	args  map[string][]map[string]any
+}
+
+// defaultTestTools are a read-only, a create and a destructive tool
+func defaultTestTools() []testTool {
+	return []testTool{
+		{name: "get_blueprints"},
+		{name: "create_resource", schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name":      map[string]any{"type": "string"},
+				"blueprint": map[string]any{"type": "string"},
+			},
+		}},
+		{name: "delete_resource"},
+	}
+}
+
+func newTestMCPServer(t *testing.T, tools ...testTool) *testMCPServer {
+	t.Helper()
+	if len(tools) == 0 {
+		tools = defaultTestTools()
+	}
+
+	s := &testMCPServer{
+		server: sdkmcp.NewServer(&sdkmcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil),
+		calls:  make(map[string]int),
+		args:   make(map[string][]map[string]any),
+	}
+	for _, tool := range tools {
+		s.addTool(tool)
+	}
+	s.Server = httptest.NewServer(sdkmcp.NewStreamableHTTPHandler(func(*http.Request) *sdkmcp.Server { return s.server }, nil))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// addTool registers tool on the server; connected clients see it on their next ListTools
+func (s *testMCPServer) addTool(tool testTool) {
+	schema := tool.schema
+	if schema == nil {
+		schema = map[string]any{"type": "object"}
+	}
+	s.server.AddTool(&sdkmcp.Tool{Name: tool.name, Description: tool.name + " test tool", InputSchema: schema},
+		func(ctx context.Context, req *sdkmcp.CallToolRequest) (*sdkmcp.CallToolResult, error) {
+			var args map[string]any
+			if len(req.Params.Arguments) > 0 {
+				if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+					return nil, err
+				}
+			}
+			s.mu.Lock()
+			s.calls[tool.name]++
+			s.args[tool.name] = append(s.args[tool.name], args)
+			s.mu.Unlock()
+
+			if tool.handler != nil {
+				return tool.handler(args)
+			}
+			return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: tool.name + " ok"}}}, nil
+		})
+}
+
+// testBlueprintsTool is a get_blueprints tool answering with the given blueprints JSON
+func testBlueprintsTool(blueprints string) testTool {
+	return testTool{name: "get_blueprints", handler: func(args map[string]any) (*sdkmcp.CallToolResult, error) {
+		return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: blueprints}}}, nil
+	}}
+}
+
+// callCount returns how many times the named tool has run
+func (s *testMCPServer) callCount(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[name]
+}
+
+// fakeReply is one scripted provider answer
+type fakeReply struct {
+	response *ai.Response
+	err      error
+}
+
+// fakeChatCall records the arguments of one Chat call
+type fakeChatCall struct {
+	prompt  string
+	tools   []*mcp.Tool
+	history []ai.Message
+	config  *ai.GenerationConfig
+}
+
+// fakeProvider answers Chat from a script, then with a plain "done" reply
+type fakeProvider struct {
+	name string
+
+	mu      sync.Mutex
+	replies []fakeReply
+	calls   []fakeChatCall
+	closed  bool
+}
+
+func newFakeProvider(name string, replies ...fakeReply) *fakeProvider {
+	return &fakeProvider{name: name, replies: replies}
+}
+
+func (p *fakeProvider) Chat(ctx context.Context, prompt string, tools []*mcp.Tool, conversationHistory []ai.Message, genConfig *ai.GenerationConfig) (*ai.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = append(p.calls, fakeChatCall{
+		prompt:  prompt,
+		tools:   tools,
+		history: append([]ai.Message(nil), conversationHistory...),
+		config:  genConfig,
+	})
+	if len(p.replies) == 0 {
+		return &ai.Response{Content: "done", FinishReason: "stop"}, nil
+	}
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return reply.response, reply.err
+}
+
+func (p *fakeProvider) GetProviderName() string { return p.name }
+
+func (p *fakeProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// chatCalls returns the Chat calls made so far
+func (p *fakeProvider) chatCalls() []fakeChatCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]fakeChatCall(nil), p.calls...)
+}
+
+// isClosed reports whether Close has been called
+func (p *fakeProvider) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// toolCallReply scripts a response that calls the given tools
+func toolCallReply(calls ...ai.ToolCall) fakeReply {
+	return fakeReply{response: &ai.Response{ToolCalls: calls, FinishReason: "tool_calls"}}
+}
+
+// textReply scripts a final text response
+func textReply(content string) fakeReply {
+	return fakeReply{response: &ai.Response{Content: content, FinishReason: "stop"}}
+}
+
+// newTestService connects an OrchestrationService to server with provider as the default
+func newTestService(t *testing.T, server *testMCPServer, provider ai.Provider, maxIterations int, cacheTTL time.Duration) *OrchestrationService {
+	t.Helper()
+	return newTestServiceWithOptions(t, server, mcp.ClientOptions{}, provider, maxIterations, cacheTTL)
+}
+
+// newTestServiceWithOptions is newTestService with a configured MCP client, e.g. for timeouts
+func newTestServiceWithOptions(t *testing.T, server *testMCPServer, options mcp.ClientOptions, provider ai.Provider, maxIterations int, cacheTTL time.Duration) *OrchestrationService {
+	t.Helper()
+	client, err := mcp.NewClient(server.URL, nil, options)
+	if err != nil {
+		t.Fatalf("mcp.NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	factories := map[string]ai.ProviderFactory{
+		provider.GetProviderName(): func(model string) (ai.Provider, error) { return provider, nil },
+	}
+	service, err := NewOrchestrationService(client, factories, provider.GetProviderName(), maxIterations, cacheTTL)
+	if err != nil {
+		t.Fatalf("NewOrchestrationService: %v", err)
+	}
+	t.Cleanup(func() { service.Close() })
+	return service
+}
+
+// runPrompt sends prompt through service and fails the test if processing fails
+func runPrompt(t *testing.T, service *OrchestrationService, prompt string) *models.ChatResponse {
+	t.Helper()
+	resp, err := service.ProcessPrompt(context.Background(), &models.ChatRequest{Prompt: prompt})
+	if err != nil {
+		t.Fatalf("ProcessPrompt(%q): %v", prompt, err)
+	}
+	return resp
+}
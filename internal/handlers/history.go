@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+// DefaultHistoryTokenBudget is the estimated token budget for history plus the current
+// prompt when no budget is configured for a provider
+const DefaultHistoryTokenBudget = 32000
+
+// MaxContextOverflowRetries bounds how often a turn is retried with half the history after
+// the provider reports the context window was exceeded
+const MaxContextOverflowRetries = 3
+
+// HistoryBudgets holds the estimated token budget for conversation history, per provider
+type HistoryBudgets struct {
+	defaultBudget int
+	perProvider   map[string]int
+}
+
+// NewHistoryBudgets creates budgets from a default and provider name -> token overrides.
+// A non-positive default falls back to DefaultHistoryTokenBudget
+func NewHistoryBudgets(defaultBudget int, perProvider map[string]int) *HistoryBudgets {
+	if defaultBudget <= 0 {
+		defaultBudget = DefaultHistoryTokenBudget
+	}
+	return &HistoryBudgets{defaultBudget: defaultBudget, perProvider: perProvider}
+}
+
+// For returns the token budget for a provider
+func (b *HistoryBudgets) For(provider string) int {
+	if b == nil {
+		return DefaultHistoryTokenBudget
+	}
+	if budget, ok := b.perProvider[provider]; ok && budget > 0 {
+		return budget
+	}
+	return b.defaultBudget
+}
+
+// estimateTokens roughly estimates the token count of text at four characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// estimateMessageTokens estimates the tokens a history message costs, including its
+// tool calls and tool results
+func estimateMessageTokens(msg ai.Message) int {
+	tokens := estimateTokens(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		argsJSON, _ := json.Marshal(tc.Arguments)
+		tokens += estimateTokens(tc.Name) + estimateTokens(string(argsJSON))
+	}
+	for _, result := range msg.ToolResults {
+		tokens += estimateTokens(result.Content)
+	}
+	return tokens
+}
+
+// estimateHistoryTokens estimates the tokens of the history plus the current prompt
+func estimateHistoryTokens(history []ai.Message, prompt string) int {
+	total := estimateTokens(prompt)
+	for _, msg := range history {
+		total += estimateMessageTokens(msg)
+	}
+	return total
+}
+
+// trimHistory drops the oldest history messages until the history plus the current prompt
+// fit in budget, returning the kept messages and how many were dropped. The current prompt
+// is never dropped, and tool results whose calls were dropped go with them
+func trimHistory(history []ai.Message, prompt string, budget int) ([]ai.Message, int) {
+	total := estimateHistoryTokens(history, prompt)
+
+	start := 0
+	for start < len(history) && total > budget {
+		total -= estimateMessageTokens(history[start])
+		start++
+		// Results must follow the call that produced them, so don't keep them orphaned
+		for start < len(history) && len(history[start].ToolResults) > 0 {
+			total -= estimateMessageTokens(history[start])
+			start++
+		}
+	}
+
+	return history[start:], start
+}
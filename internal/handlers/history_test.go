@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+// sized returns a message of role whose content estimates to tokens tokens
+func sized(role string, tokens int) ai.Message {
+	return ai.Message{Role: role, Content: strings.Repeat("x", tokens*4)}
+}
+
+func TestTrimHistory(t *testing.T) {
+	call := ai.Message{Role: "assistant", ToolCalls: []ai.ToolCall{{ID: "1", Name: "get_blueprints"}}}
+	result := ai.Message{Role: "user", ToolResults: []ai.ToolResult{{ToolCallID: "1", Content: strings.Repeat("x", 400)}}}
+	prompt := strings.Repeat("x", 40) // 10 tokens
+
+	tests := []struct {
+		name        string
+		history     []ai.Message
+		budget      int
+		wantKept    []ai.Message
+		wantDropped int
+	}{
+		{
+			name:     "under budget keeps everything",
+			history:  []ai.Message{sized("user", 10), sized("assistant", 10)},
+			budget:   100,
+			wantKept: []ai.Message{sized("user", 10), sized("assistant", 10)},
+		},
+		{
+			name:        "drops oldest first",
+			history:     []ai.Message{sized("user", 50), sized("assistant", 50), sized("user", 20), sized("assistant", 20)},
+			budget:      60,
+			wantKept:    []ai.Message{sized("user", 20), sized("assistant", 20)},
+			wantDropped: 2,
+		},
+		{
+			name:        "tool results go with their call",
+			history:     []ai.Message{sized("user", 5), call, result, sized("assistant", 20)},
+			budget:      100,
+			wantKept:    []ai.Message{sized("assistant", 20)},
+			wantDropped: 3,
+		},
+		{
+			name:        "prompt alone over budget drops all history",
+			history:     []ai.Message{sized("user", 10)},
+			budget:      5,
+			wantKept:    []ai.Message{},
+			wantDropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := trimHistory(tt.history, prompt, tt.budget)
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", dropped, tt.wantDropped)
+			}
+			if !reflect.DeepEqual(kept, tt.wantKept) {
+				t.Errorf("kept %d messages, want %d", len(kept), len(tt.wantKept))
+			}
+			if len(kept) > 0 && estimateHistoryTokens(kept, prompt) > tt.budget {
+				t.Errorf("kept history estimates %d tokens, over the %d budget", estimateHistoryTokens(kept, prompt), tt.budget)
+			}
+		})
+	}
+}
+
+func TestHistoryBudgetsFor(t *testing.T) {
+	budgets := NewHistoryBudgets(0, map[string]int{"ollama": 4000, "gemini": 0})
+	tests := []struct {
+		provider string
+		want     int
+	}{
+		{"ollama", 4000},
+		{"gemini", DefaultHistoryTokenBudget}, // non-positive overrides are ignored
+		{"openai", DefaultHistoryTokenBudget},
+	}
+	for _, tt := range tests {
+		if got := budgets.For(tt.provider); got != tt.want {
+			t.Errorf("For(%q) = %d, want %d", tt.provider, got, tt.want)
+		}
+	}
+
+	var nilBudgets *HistoryBudgets
+	if got := nilBudgets.For("openai"); got != DefaultHistoryTokenBudget {
+		t.Errorf("nil budgets For = %d, want %d", got, DefaultHistoryTokenBudget)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// ToolResultHook transforms a tool result before the model sees it. It receives the
+// raw MCP result and the default formatting, and returns the text to feed back.
+type ToolResultHook func(result *mcp.CallToolResult, formatted string) string
+
+// ToolResultHooks holds post-processing hooks registered per tool name
+type ToolResultHooks struct {
+	hooks map[string]ToolResultHook
+	mu    sync.RWMutex
+}
+
+// NewToolResultHooks creates an empty hook registry
+func NewToolResultHooks() *ToolResultHooks {
+	return &ToolResultHooks{
+		hooks: make(map[string]ToolResultHook),
+	}
+}
+
+// Register sets the hook for a tool, replacing any existing one
+func (h *ToolResultHooks) Register(toolName string, hook ToolResultHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks[toolName] = hook
+}
+
+// Apply runs the hook registered for the tool, if any, on the formatted result
+func (h *ToolResultHooks) Apply(toolName string, result *mcp.CallToolResult, formatted string) string {
+	h.mu.RLock()
+	hook, exists := h.hooks[toolName]
+	h.mu.RUnlock()
+
+	if !exists {
+		return formatted
+	}
+	return hook(result, formatted)
+}
+
+// StripJSONFieldsHook returns a hook that removes the named fields from JSON results
+// (objects, or arrays of objects, at any depth). Non-JSON results are left unchanged.
+func StripJSONFieldsHook(fields []string) ToolResultHook {
+	strip := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		strip[f] = true
+	}
+
+	return func(result *mcp.CallToolResult, formatted string) string {
+		var data interface{}
+		if err := json.Unmarshal([]byte(formatted), &data); err != nil {
+			return formatted
+		}
+
+		stripped, err := json.Marshal(stripFields(data, strip))
+		if err != nil {
+			return formatted
+		}
+		return string(stripped)
+	}
+}
+
+func stripFields(v interface{}, strip map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if strip[k] {
+				delete(val, k)
+			} else {
+				val[k] = stripFields(item, strip)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = stripFields(item, strip)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// ChatLimiter caps the number of chat orchestrations running at once.
+// Excess requests wait up to queueTimeout for a free slot before being rejected.
+type ChatLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+	inFlight     int64
+	queued       int64
+}
+
+// NewChatLimiter creates a limiter allowing maxConcurrent chats; zero or less disables the limit
+func NewChatLimiter(maxConcurrent int, queueTimeout time.Duration) *ChatLimiter {
+	limiter := &ChatLimiter{queueTimeout: queueTimeout}
+	if maxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, maxConcurrent)
+	}
+	return limiter
+}
+
+// Acquire reserves a slot, waiting up to the queue timeout. It returns false if no
+// slot became free in time; callers that get true must call Release when done.
+func (l *ChatLimiter) Acquire(ctx context.Context) bool {
+	if l.slots == nil {
+		atomic.AddInt64(&l.inFlight, 1)
+		return true
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		atomic.AddInt64(&l.inFlight, 1)
+		return true
+	default:
+	}
+
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	atomic.AddInt64(&l.queued, 1)
+	defer atomic.AddInt64(&l.queued, -1)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		atomic.AddInt64(&l.inFlight, 1)
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Release frees a slot reserved by Acquire
+func (l *ChatLimiter) Release() {
+	atomic.AddInt64(&l.inFlight, -1)
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// RetryAfter suggests how long a rejected client should wait before retrying
+func (l *ChatLimiter) RetryAfter() time.Duration {
+	if l.queueTimeout > time.Second {
+		return l.queueTimeout
+	}
+	return time.Second
+}
+
+// Stats returns the current in-flight count, queue depth and limit
+func (l *ChatLimiter) Stats() map[string]int {
+	return map[string]int{
+		"in_flight": int(atomic.LoadInt64(&l.inFlight)),
+		"queued":    int(atomic.LoadInt64(&l.queued)),
+		"limit":     cap(l.slots),
+	}
+}
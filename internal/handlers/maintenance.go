@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// ErrMaintenance is returned for operations that would change infrastructure while
+// maintenance mode is on
+var ErrMaintenance = errors.New("maintenance in progress: creating, updating and deleting resources is temporarily disabled")
+
+// mutatingToolPrefixes mark tools that change infrastructure, in addition to destructive ones
+var mutatingToolPrefixes = []string{"create_", "update_", "patch_", "apply_", "deploy_", "provision_", "scale_"}
+
+// isMutatingTool reports whether a tool changes infrastructure and is blocked in maintenance mode.
+// Tools the MCP server annotates as read-only never are; otherwise the tool name, after any
+// namespace such as "cloudgenie_" is stripped, must start with a mutating verb, so
+// "get_update_status" and "list_deployments" keep working during maintenance
+func isMutatingTool(tool *mcp.Tool, toolName string) bool {
+	if tool != nil && tool.Annotations != nil && tool.Annotations.ReadOnlyHint {
+		return false
+	}
+	if isDestructiveTool(tool, toolName) {
+		return true
+	}
+	return hasToolPrefix(toolName, mutatingToolPrefixes)
+}
+
+// maintenanceMode is the runtime maintenance toggle
+type maintenanceMode struct {
+	mu      sync.RWMutex
+	enabled bool
+	since   time.Time // when maintenance was last turned on
+}
+
+// SetMaintenanceMode turns maintenance mode on or off. While on, mutating tool calls are
+// refused and confirmed destructive calls fail with ErrMaintenance; reads and chat still work
+func (s *OrchestrationService) SetMaintenanceMode(enabled bool) {
+	s.maintenance.mu.Lock()
+	defer s.maintenance.mu.Unlock()
+	if enabled && !s.maintenance.enabled {
+		s.maintenance.since = time.Now()
+	}
+	s.maintenance.enabled = enabled
+}
+
+// MaintenanceMode reports whether maintenance mode is on and since when
+func (s *OrchestrationService) MaintenanceMode() (bool, time.Time) {
+	s.maintenance.mu.RLock()
+	defer s.maintenance.mu.RUnlock()
+	return s.maintenance.enabled, s.maintenance.since
+}
+
+// inMaintenance reports whether maintenance mode is on
+func (s *OrchestrationService) inMaintenance() bool {
+	enabled, _ := s.MaintenanceMode()
+	return enabled
+}
+
+// maintenanceResult is the tool result returned when the AI calls a mutating tool during maintenance
+func maintenanceResult(name string) (string, ToolError) {
+	return fmt.Sprintf("%s was NOT executed: maintenance is in progress, so resources cannot be created, updated or deleted right now. Tell the user to try again after maintenance; read-only tools still work.", name),
+		ToolError{Type: ToolErrorTransient, Code: "maintenance_in_progress", Retryable: false}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestIsMutatingTool(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     *sdkmcp.Tool
+		mutating bool
+	}{
+		{"create_resource", nil, true},
+		{"cloudgenie_update_resource", nil, true},
+		{"scale_deployment", nil, true},
+		{"delete_resource", nil, true},
+		{"get_update_status", nil, false},
+		{"list_deployments", nil, false},
+		{"describe_scale_policy", nil, false},
+		{"cloudgenie_get_blueprints", nil, false},
+		{"apply_plan", &sdkmcp.Tool{Annotations: &sdkmcp.ToolAnnotations{ReadOnlyHint: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMutatingTool(tt.tool, tt.name); got != tt.mutating {
+				t.Errorf("isMutatingTool(%q) = %v, want %v", tt.name, got, tt.mutating)
+			}
+		})
+	}
+}
+
+func TestProcessPromptRunsReadToolsInMaintenance(t *testing.T) {
+	reads := []string{"get_update_status", "list_deployments", "describe_scale_policy"}
+	server := newTestMCPServer(t,
+		testTool{name: reads[0]},
+		testTool{name: reads[1]},
+		testTool{name: reads[2]},
+		testTool{name: "create_resource"},
+	)
+	provider := newFakeProvider("fake",
+		toolCallReply(
+			ai.ToolCall{ID: "1", Name: reads[0], Arguments: map[string]interface{}{}},
+			ai.ToolCall{ID: "2", Name: reads[1], Arguments: map[string]interface{}{}},
+			ai.ToolCall{ID: "3", Name: reads[2], Arguments: map[string]interface{}{}},
+			ai.ToolCall{ID: "4", Name: "create_resource", Arguments: map[string]interface{}{"name": "db"}},
+		),
+		textReply("done"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+	service.SetMaintenanceMode(true)
+
+	resp := runPrompt(t, service, "status and a new db")
+
+	for _, name := range reads {
+		if got := server.callCount(name); got != 1 {
+			t.Errorf("%s ran %d times during maintenance, want 1", name, got)
+		}
+	}
+	if got := server.callCount("create_resource"); got != 0 {
+		t.Errorf("create_resource ran %d times during maintenance, want 0", got)
+	}
+	for _, result := range resp.ToolResults {
+		if wantErr := result.Name == "create_resource"; result.IsError != wantErr {
+			t.Errorf("%s result IsError = %v, want %v", result.Name, result.IsError, wantErr)
+		}
+	}
+}
@@ -4,26 +4,42 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/logging"
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
 )
 
+// ErrAIProvider wraps errors returned by the AI provider
+var ErrAIProvider = errors.New("AI provider error")
+
+// ErrAIUnavailable wraps transient provider failures (outages, rate limits, timeouts) that
+// persisted through every retry, as opposed to errors caused by the request itself
+var ErrAIUnavailable = errors.New("AI provider unavailable")
+
+// AIUnavailableRetryAfter is the Retry-After sent with ai_unavailable errors
+const AIUnavailableRetryAfter = 30 * time.Second
+
 const (
-	MaxToolIterations = 5
-	CacheTTL          = 5 * time.Minute // Cache results for 5 minutes
+	DefaultMaxToolIterations  = 5
+	AbsoluteMaxToolIterations = 20              // Upper bound for per-request max_iterations overrides
+	DefaultCacheTTL           = 5 * time.Minute // Cache results for 5 minutes
 )
 
 // ResultCache provides thread-safe caching of tool results with TTL
 type ResultCache struct {
-	store map[string]*CachedResult
-	mu    sync.RWMutex
-	ttl   time.Duration
+	store     map[string]*CachedResult
+	mu        sync.RWMutex
+	ttl       time.Duration
+	done      chan struct{} // closed by Close to stop the cleanup goroutine
+	closeOnce sync.Once
 }
 
 type CachedResult struct {
@@ -32,42 +48,53 @@ type CachedResult struct {
 	IsError   bool
 }
 
-// NewResultCache creates a new result cache with specified TTL
+// NewResultCache creates a new result cache with specified TTL. A TTL of zero or less disables caching
 func NewResultCache(ttl time.Duration) *ResultCache {
 	cache := &ResultCache{
 		store: make(map[string]*CachedResult),
 		ttl:   ttl,
+		done:  make(chan struct{}),
 	}
-	
+
 	// Start cleanup goroutine
-	go cache.cleanupExpired()
-	
+	if ttl > 0 {
+		go cache.cleanupExpired()
+	}
+
 	return cache
 }
 
 // Get retrieves a cached result if it exists and hasn't expired
 func (c *ResultCache) Get(key string) (*CachedResult, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	
+
 	result, exists := c.store[key]
 	if !exists {
 		return nil, false
 	}
-	
+
 	// Check if expired
 	if time.Since(result.Timestamp) > c.ttl {
 		return nil, false
 	}
-	
+
 	return result, true
 }
 
 // Set stores a result in the cache
 func (c *ResultCache) Set(key string, content string, isError bool) {
+	if c.ttl <= 0 {
+		return
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
+
 	c.store[key] = &CachedResult{
 		Content:   content,
 		Timestamp: time.Now(),
@@ -75,12 +102,18 @@ func (c *ResultCache) Set(key string, content string, isError bool) {
 	}
 }
 
-// cleanupExpired removes expired entries every minute
+// cleanupExpired removes expired entries every minute until the cache is closed
 func (c *ResultCache) cleanupExpired() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
-	
-	for range ticker.C {
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
 		c.mu.Lock()
 		now := time.Now()
 		for key, result := range c.store {
@@ -92,11 +125,16 @@ func (c *ResultCache) cleanupExpired() {
 	}
 }
 
+// Close stops the cleanup goroutine. It is safe to call more than once
+func (c *ResultCache) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
 // Stats returns cache statistics
 func (c *ResultCache) Stats() map[string]int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	
+
 	return map[string]int{
 		"total_entries": len(c.store),
 	}
@@ -104,148 +142,519 @@ func (c *ResultCache) Stats() map[string]int {
 
 // OrchestrationService coordinates between AI and MCP server
 type OrchestrationService struct {
-	mcpClient   *mcp.Client
-	aiProvider  ai.Provider
-	tools       []*mcp.Tool
-	resultCache *ResultCache
+	mcpClient     *mcp.Client
+	tools         []*mcp.Tool // guarded by toolsMu; replaced by RefreshTools
+	toolsMu       sync.RWMutex
+	resultCache   *ResultCache
+	confirmations *ConfirmationStore
+	resultHooks   *ToolResultHooks
+
+	cacheablePrefixes []string // tool-name prefixes of read-only tools whose results are cached
+	maxToolIterations int      // tool iterations per request unless the request overrides it
+
+	providers         map[string]ai.Provider        // initialized providers with their default models, keyed by name
+	providerFactories map[string]ai.ProviderFactory // builds a provider for a per-request model override
+	modelProviders    *ProviderCache                // clients for model overrides, keyed by provider/model
+	defaultProvider   string                        // provider used when a request doesn't name one
+	providerLimiter   *ProviderRateLimiter          // global per-provider requests-per-minute limit
+	historyBudgets    *HistoryBudgets               // estimated token budget for history sent to each provider
+	conversations     ConversationRepository        // stores session history for requests with a session_id; nil = stateless
+	toolFilter        *ToolFilter                   // tools the AI may see and call; nil = all
+
+	defaultBlueprints map[string]string // resource-type keyword -> blueprint used when a create call names none
+
+	maintenance     maintenanceMode  // blocks mutating tools while on
+	blueprintCache  *BlueprintCache  // last blueprints tool result, for the blueprint endpoints
+	blueprintFilter *BlueprintFilter // hides internal blueprints by category or group; nil = all
+	blockDeprecated bool             // reject creates against deprecated blueprints instead of warning
+
+	done      chan struct{} // closed by Close to stop background work
+	closeOnce sync.Once
 }
 
-func NewOrchestrationService(mcpClient *mcp.Client, aiProvider ai.Provider) (*OrchestrationService, error) {
+// NewOrchestrationService creates the service, initializing a provider for every configured factory.
+// maxToolIterations defaults to DefaultMaxToolIterations when not positive; a cacheTTL of zero disables
+// the tool result cache
+func NewOrchestrationService(mcpClient *mcp.Client, providerFactories map[string]ai.ProviderFactory, defaultProvider string, maxToolIterations int, cacheTTL time.Duration) (*OrchestrationService, error) {
+	if maxToolIterations <= 0 {
+		maxToolIterations = DefaultMaxToolIterations
+	}
+
+	providers, err := initProviders(providerFactories, defaultProvider)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize MCP client and get tools
-	if err := mcpClient.Initialize(); err != nil {
+	ctx := context.Background()
+	if err := mcpClient.Initialize(ctx); err != nil {
+		closeProviders(providers)
 		return nil, fmt.Errorf("failed to initialize MCP client: %w", err)
 	}
 
-	tools, err := mcpClient.ListTools()
+	tools, err := mcpClient.ListTools(ctx)
 	if err != nil {
+		closeProviders(providers)
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
 
 	return &OrchestrationService{
-		mcpClient:   mcpClient,
-		aiProvider:  aiProvider,
-		tools:       tools,
-		resultCache: NewResultCache(CacheTTL),
+		mcpClient:         mcpClient,
+		tools:             tools,
+		resultCache:       NewResultCache(cacheTTL),
+		blueprintCache:    NewBlueprintCache(DefaultBlueprintCacheTTL),
+		confirmations:     NewConfirmationStore(ConfirmationTTL),
+		resultHooks:       NewToolResultHooks(),
+		cacheablePrefixes: DefaultCacheableToolPrefixes,
+		maxToolIterations: maxToolIterations,
+		providers:         providers,
+		providerFactories: providerFactories,
+		modelProviders:    NewProviderCache(MaxModelProviders),
+		defaultProvider:   defaultProvider,
+		done:              make(chan struct{}),
 	}, nil
 }
 
-// generateCacheKey creates a deterministic cache key from tool name and arguments
-func generateCacheKey(toolName string, args map[string]interface{}) string {
+// RegisterToolResultHook registers a hook that post-processes the results of a tool
+// before they are fed back to the model
+func (s *OrchestrationService) RegisterToolResultHook(toolName string, hook ToolResultHook) {
+	s.resultHooks.Register(toolName, hook)
+}
+
+// SetProviderRateLimiter sets the limiter applied to every AI provider call
+func (s *OrchestrationService) SetProviderRateLimiter(limiter *ProviderRateLimiter) {
+	s.providerLimiter = limiter
+}
+
+// SetCacheableToolPrefixes sets the tool-name prefixes (e.g. "get_") of read-only tools
+// whose results are cached. Other tools always run
+func (s *OrchestrationService) SetCacheableToolPrefixes(prefixes []string) {
+	s.cacheablePrefixes = prefixes
+}
+
+// SetHistoryBudgets sets the token budgets used to trim conversation history before
+// each provider call
+func (s *OrchestrationService) SetHistoryBudgets(budgets *HistoryBudgets) {
+	s.historyBudgets = budgets
+}
+
+// SetToolFilter restricts the tools the AI sees in its prompt and may call
+func (s *OrchestrationService) SetToolFilter(filter *ToolFilter) {
+	s.toolsMu.Lock()
+	defer s.toolsMu.Unlock()
+
+	s.toolFilter = filter
+	allowed := filter.Filter(s.tools)
+	if hidden := len(s.tools) - len(allowed); hidden > 0 {
+		log.Printf("Tool filter hides %d of %d MCP tools", hidden, len(s.tools))
+	}
+	s.tools = allowed
+}
+
+// SetConversationRepository sets the store used to keep the history of requests that
+// carry a session_id
+func (s *OrchestrationService) SetConversationRepository(repo ConversationRepository) {
+	s.conversations = repo
+}
+
+// SetDefaultBlueprints sets the resource-type keyword to blueprint mapping used when
+// a create call doesn't specify a blueprint
+func (s *OrchestrationService) SetDefaultBlueprints(defaults map[string]string) {
+	s.defaultBlueprints = defaults
+}
+
+// findTool returns the tool with the given name, or nil if it isn't available
+func (s *OrchestrationService) findTool(name string) *mcp.Tool {
+	for _, tool := range s.currentTools() {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+// effectiveMaxIterations applies a per-request override to the configured iteration
+// limit, capped at AbsoluteMaxToolIterations (or the configured limit, if higher)
+func (s *OrchestrationService) effectiveMaxIterations(requested int) int {
+	if requested <= 0 {
+		return s.maxToolIterations
+	}
+	limit := AbsoluteMaxToolIterations
+	if s.maxToolIterations > limit {
+		limit = s.maxToolIterations
+	}
+	if requested > limit {
+		return limit
+	}
+	return requested
+}
+
+// generateCacheKey creates a deterministic cache key from the AI provider, tool name and
+// arguments, so results cached for one provider aren't served to another
+func generateCacheKey(providerName, toolName string, args map[string]interface{}) string {
 	// Serialize arguments to JSON for consistent hashing
 	argsJSON, err := json.Marshal(args)
 	if err != nil {
-		// If marshaling fails, use tool name only (no caching benefit for this call)
-		return toolName
+		// If marshaling fails, use provider and tool name only (no caching benefit for this call)
+		return providerName + ":" + toolName
 	}
-	
-	// Create SHA256 hash of tool name + args
-	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s", toolName, argsJSON)))
-	return fmt.Sprintf("%s:%x", toolName, hash[:8]) // Use first 8 bytes for readability
+
+	// Create SHA256 hash of provider + tool name + args
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%s", providerName, toolName, argsJSON)))
+	return fmt.Sprintf("%s:%s:%x", providerName, toolName, hash[:8]) // Use first 8 bytes for readability
 }
 
 // ProcessPrompt processes a user prompt and coordinates with AI and MCP
 func (s *OrchestrationService) ProcessPrompt(ctx context.Context, request *models.ChatRequest) (*models.ChatResponse, error) {
+	aiProvider, release, err := s.selectProvider(request.Provider, request.Model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	genConfig := generationConfigFor(request)
+	// The tool list may be refreshed mid-request; keep offering the AI the same tools
+	tools := s.currentTools()
+
 	conversationHistory := []ai.Message{}
+	if request.SessionID != "" {
+		if s.conversations == nil {
+			return nil, ErrSessionsDisabled
+		}
+		history, err := s.conversations.GetConversation(ctx, request.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		conversationHistory = append(conversationHistory, history...)
+	}
 	allToolCalls := []models.ToolCall{}
 	allToolResults := []models.ToolResult{}
-	
+	pendingConfirmations := []models.PendingConfirmation{}
+	intermediateResponses := []string{}
+
 	// Cache metrics
 	cacheHits := 0
 	cacheMisses := 0
 
+	// Results of read-only calls already run in this request, keyed like the result cache,
+	// so a repeated call is answered without another round trip to the MCP server
+	executedReads := make(map[string]string)
+	duplicateCalls := 0
+
+	// Token usage summed across iterations; providers that don't report usage contribute nothing
+	usage := ai.Usage{}
+
+	// Time spent per phase, reported in metadata
+	timings := newPhaseTimings()
+
+	// Oldest history messages dropped to stay within the provider's context budget
+	historyDropped := 0
+	// Turns retried with less history after the provider reported a context overflow
+	contextRetries := 0
+
 	currentPrompt := request.Prompt
 	iteration := 0
 
-	for iteration < MaxToolIterations {
+	// Run a previously held destructive call once the user has confirmed it
+	if request.ConfirmationToken != "" {
+		// Checked before consuming so the token still works once maintenance ends
+		if s.inMaintenance() {
+			return nil, ErrMaintenance
+		}
+		pending, err := s.confirmations.Consume(request.ConfirmationToken)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("Executing confirmed tool: %s with args: %s", pending.ToolName, logging.Sprint(pending.Arguments))
+		var resultContent string
+		var isError bool
+		var toolErr ToolError
+		if !s.toolFilter.Allows(pending.ToolName) {
+			resultContent, toolErr = notAllowedResult(pending.ToolName)
+			isError = true
+		} else if mcpResult, err := s.timedCallTool(ctx, timings, pending.ToolName, pending.Arguments); err != nil {
+			resultContent = fmt.Sprintf("Error calling tool %s: %v", pending.ToolName, err)
+			isError = true
+			toolErr = classifyCallError(err)
+		} else {
+			resultContent = s.processToolResult(pending.ToolName, pending.Arguments, mcpResult)
+			isError = mcpResult.IsError
+			if isError {
+				toolErr = classifyToolResultError(resultContent)
+			}
+		}
+
+		allToolCalls = append(allToolCalls, models.ToolCall{
+			ID:        pending.Token,
+			Name:      pending.ToolName,
+			Arguments: pending.Arguments,
+			Iteration: 0,
+			Sequence:  1,
+		})
+		allToolResults = append(allToolResults, newToolResult(pending.Token, pending.ToolName, resultContent, isError, toolErr, 0))
+
+		currentPrompt = fmt.Sprintf("%s\n\nThe user confirmed the %s call. %s", request.Prompt, pending.ToolName,
+			formatToolResultsForPrompt([]ai.ToolResult{{ToolCallID: pending.Token, Content: resultContent, IsError: isError}}))
+	}
+
+	// Messages added by this request, saved to the session once it completes.
+	// The user turn includes the outcome of a confirmed call
+	newMessages := []ai.Message{{Role: "user", Content: currentPrompt}}
+
+	maxIterations := s.effectiveMaxIterations(request.MaxIterations)
+
+	for iteration < maxIterations {
 		iteration++
 
+		// Stay within the provider's global request budget
+		if err := s.waitForProvider(ctx, timings, aiProvider.GetProviderName()); err != nil {
+			return nil, err
+		}
+
+		// Keep the history plus the current prompt within the provider's context budget
+		trimmed, dropped := trimHistory(conversationHistory, currentPrompt, s.historyBudgets.For(aiProvider.GetProviderName()))
+		if dropped > 0 {
+			log.Printf("Dropped %d oldest history messages to fit the %s context budget", dropped, aiProvider.GetProviderName())
+			conversationHistory = trimmed
+			historyDropped += dropped
+		}
+
 		// Call AI with current prompt and tools
-		aiResponse, err := s.aiProvider.Chat(ctx, currentPrompt, s.tools, conversationHistory)
+		aiStart := time.Now()
+		aiResponse, err := aiProvider.Chat(ctx, currentPrompt, tools, conversationHistory, genConfig)
+		timings.ai += time.Since(aiStart)
+
+		// The token estimate can undershoot the model's real window: halve the history and retry the turn
+		for attempt := 0; ai.IsContextLengthError(err) && attempt < MaxContextOverflowRetries && len(conversationHistory) > 0; attempt++ {
+			trimmed, dropped := trimHistory(conversationHistory, currentPrompt, estimateHistoryTokens(conversationHistory, currentPrompt)/2)
+			log.Printf("%s context window exceeded; retrying with %d fewer history messages", aiProvider.GetProviderName(), dropped)
+			conversationHistory = trimmed
+			historyDropped += dropped
+			contextRetries++
+
+			if err := s.waitForProvider(ctx, timings, aiProvider.GetProviderName()); err != nil {
+				return nil, err
+			}
+			aiStart = time.Now()
+			aiResponse, err = aiProvider.Chat(ctx, currentPrompt, tools, conversationHistory, genConfig)
+			timings.ai += time.Since(aiStart)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("AI provider error: %w", err)
+			if ctx.Err() == nil && ai.IsRetryableError(err) {
+				return nil, fmt.Errorf("%w: %s: %w", ErrAIUnavailable, aiProvider.GetProviderName(), err)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrAIProvider, err)
+		}
+		if aiResponse.Usage != nil {
+			usage.PromptTokens += aiResponse.Usage.PromptTokens
+			usage.CompletionTokens += aiResponse.Usage.CompletionTokens
+			usage.TotalTokens += aiResponse.Usage.TotalTokens
+			usage.ReasoningTokens += aiResponse.Usage.ReasoningTokens
 		}
 
 		// Add assistant response to history
-		conversationHistory = append(conversationHistory, ai.Message{
-			Role:    "assistant",
-			Content: aiResponse.Content,
-		})
+		assistantMessage := ai.Message{
+			Role:      "assistant",
+			Content:   aiResponse.Content,
+			ToolCalls: aiResponse.ToolCalls,
+		}
+		conversationHistory = append(conversationHistory, assistantMessage)
+		newMessages = append(newMessages, assistantMessage)
 
 		// If no tool calls, we're done
 		if len(aiResponse.ToolCalls) == 0 {
+			s.saveConversation(ctx, request.SessionID, newMessages)
 			return &models.ChatResponse{
-				Response:    aiResponse.Content,
-				ToolCalls:   allToolCalls,
-				ToolResults: allToolResults,
+				SessionID:             request.SessionID,
+				Response:              aiResponse.Content,
+				ToolCalls:             allToolCalls,
+				ToolResults:           allToolResults,
+				PendingConfirmations:  pendingConfirmations,
+				IntermediateResponses: intermediateResponsesFor(request, intermediateResponses),
 				Metadata: map[string]interface{}{
-					"iterations":      iteration,
-					"finish_reason":   aiResponse.FinishReason,
-					"provider":        s.aiProvider.GetProviderName(),
-					"tools_available": len(s.tools),
-					"cache_hits":      cacheHits,
-					"cache_misses":    cacheMisses,
-					"cache_stats":     s.resultCache.Stats(),
+					"iterations":                iteration,
+					"max_iterations":            maxIterations,
+					"max_iterations_configured": s.maxToolIterations,
+					"finish_reason":             aiResponse.FinishReason,
+					"provider":                  aiProvider.GetProviderName(),
+					"tools_available":           len(tools),
+					"cache_hits":                cacheHits,
+					"cache_misses":              cacheMisses,
+					"cache_stats":               s.resultCache.Stats(),
+					"duplicate_tool_calls":      duplicateCalls,
+					"usage":                     usage,
+					"history_messages_dropped":  historyDropped,
+					"context_overflow_retries":  contextRetries,
+					"timings":                   timings.metadata(),
 				},
 			}, nil
 		}
 
+		// Keep the narrative that accompanied the tool calls ("Let me check...")
+		if narrative := narrativeText(aiResponse.Content); narrative != "" {
+			intermediateResponses = append(intermediateResponses, narrative)
+		}
+
 		// Execute tool calls
 		toolResults := []ai.ToolResult{}
-		for _, toolCall := range aiResponse.ToolCalls {
-			log.Printf("Executing tool: %s with args: %v", toolCall.Name, toolCall.Arguments)
+		// Creates that reference another create in the batch run after it
+		for _, ordered := range orderToolCalls(aiResponse.ToolCalls) {
+			toolCall := ordered.Call
+			log.Printf("Executing tool: %s with args: %s", toolCall.Name, logging.Sprint(toolCall.Arguments))
+
+			tool := s.findTool(toolCall.Name)
+			if toolCall.Arguments == nil {
+				toolCall.Arguments = make(map[string]interface{})
+			}
+
+			// Pre-fill unspecified create parameters (region, project...) from the request context
+			contextNote := fillContextParams(tool, toolCall.Arguments, request.Context)
+
+			// Fill in a default blueprint when a create call doesn't name one
+			blueprintNote, blueprintErr := resolveDefaultBlueprint(tool, toolCall.Arguments, s.defaultBlueprints)
+
+			// The blueprint a create call uses, for its provisioning estimate and deprecation
+			var blueprint *models.BlueprintExport
+			if blueprintErr == nil {
+				blueprint = s.lookupCreateBlueprint(ctx, tool, toolCall.Arguments)
+			}
+			var deprecation string
+			if blueprint != nil {
+				deprecation = deprecationWarning(blueprint.Name, blueprint.Deprecated, blueprint.DeprecationMessage)
+			}
 
 			// Generate cache key
-			cacheKey := generateCacheKey(toolCall.Name, toolCall.Arguments)
-			
+			cacheKey := generateCacheKey(aiProvider.GetProviderName(), toolCall.Name, toolCall.Arguments)
+
+			// Only read-only tools are cached; a repeated create must actually run
+			cacheable := isCacheableTool(tool, toolCall.Name, s.cacheablePrefixes)
+			var cached *CachedResult
+			var found bool
+			if cacheable {
+				cacheStart := time.Now()
+				cached, found = s.resultCache.Get(cacheKey)
+				timings.cache += time.Since(cacheStart)
+			}
+
 			// Check cache first
 			var resultContent string
 			var isError bool
-			
-			if cached, found := s.resultCache.Get(cacheKey); found {
+			var toolErr ToolError
+
+			if !s.toolFilter.Allows(toolCall.Name) {
+				// Backstop: filtered tools are never shown to the AI, but it may still name one
+				resultContent, toolErr = notAllowedResult(toolCall.Name)
+				isError = true
+				log.Printf("Rejected call to disallowed tool: %s", toolCall.Name)
+			} else if isMutatingTool(tool, toolCall.Name) && s.inMaintenance() {
+				resultContent, toolErr = maintenanceResult(toolCall.Name)
+				isError = true
+				log.Printf("Rejected call to mutating tool during maintenance: %s", toolCall.Name)
+			} else if blueprintErr != nil {
+				resultContent = blueprintErr.Error()
+				isError = true
+				toolErr = ToolError{Type: ToolErrorValidation, Code: "blueprint_ambiguous", Retryable: false}
+			} else if deprecation != "" && s.blockDeprecated {
+				resultContent, toolErr = deprecatedBlueprintResult(blueprint)
+				isError = true
+				log.Printf("Rejected create against deprecated blueprint: %s", blueprint.Name)
+			} else if isDestructiveTool(tool, toolCall.Name) {
+				// Destructive calls are held until the user confirms them with the returned token
+				pending, err := s.confirmations.Add(toolCall.Name, toolCall.Arguments)
+				if err != nil {
+					resultContent = fmt.Sprintf("Error preparing confirmation for tool %s: %v", toolCall.Name, err)
+					isError = true
+					toolErr = ToolError{Type: ToolErrorTransient, Code: "confirmation_failed", Retryable: true}
+				} else {
+					argsJSON, _ := json.Marshal(toolCall.Arguments)
+					resultContent = fmt.Sprintf("Confirmation required: %s was NOT executed. Tell the user exactly what will be deleted (%s with arguments %s) and ask them to confirm. It will only run after the user confirms.",
+						toolCall.Name, toolCall.Name, argsJSON)
+					pendingConfirmations = append(pendingConfirmations, models.PendingConfirmation{
+						Token:     pending.Token,
+						ToolName:  pending.ToolName,
+						Arguments: pending.Arguments,
+						ExpiresAt: pending.ExpiresAt,
+					})
+					log.Printf("Holding destructive tool %s for user confirmation", toolCall.Name)
+				}
+			} else if prior, ok := executedReads[cacheKey]; cacheable && ok {
+				// Already run in this request: reuse the result and steer the model back to it
+				duplicateCalls++
+				resultContent = fmt.Sprintf("%s was already called with these arguments earlier in this conversation and was NOT run again. Use this earlier result instead of calling it again:\n\n%s",
+					toolCall.Name, prior)
+				log.Printf("Skipping repeated call to tool: %s (key: %s)", toolCall.Name, cacheKey)
+			} else if found {
 				// Cache HIT
 				cacheHits++
 				resultContent = cached.Content
 				isError = cached.IsError
-				log.Printf("✓ Cache HIT for tool: %s (key: %s)", toolCall.Name, cacheKey)
+				executedReads[cacheKey] = resultContent
+				log.Printf("Cache HIT for tool: %s (key: %s)", toolCall.Name, cacheKey)
 			} else {
-				// Cache MISS - call actual MCP tool
-				cacheMisses++
-				log.Printf("✗ Cache MISS for tool: %s (key: %s)", toolCall.Name, cacheKey)
-				
-				mcpResult, err := s.mcpClient.CallTool(toolCall.Name, toolCall.Arguments)
+				// Cache MISS (or uncacheable tool) - call actual MCP tool
+				if cacheable {
+					cacheMisses++
+					log.Printf("Cache MISS for tool: %s (key: %s)", toolCall.Name, cacheKey)
+				}
+
+				mcpResult, err := s.timedCallTool(ctx, timings, toolCall.Name, toolCall.Arguments)
 				if err != nil {
+					// The client went away or timed out: stop instead of running more tools
+					if ctx.Err() != nil {
+						return nil, ctx.Err()
+					}
 					errMsg := fmt.Sprintf("Error calling tool %s: %v", toolCall.Name, err)
 					log.Printf(errMsg)
-					
+
 					resultContent = errMsg
 					isError = true
-					
+
 					toolResults = append(toolResults, ai.ToolResult{
 						ToolCallID: toolCall.ID,
 						Content:    errMsg,
 						IsError:    true,
 					})
 
-					allToolResults = append(allToolResults, models.ToolResult{
-						ToolCallID: toolCall.ID,
-						Name:       toolCall.Name,
-						Content:    errMsg,
-						IsError:    true,
+					allToolResults = append(allToolResults, newToolResult(toolCall.ID, toolCall.Name, errMsg, true, classifyCallError(err), iteration))
+					allToolCalls = append(allToolCalls, models.ToolCall{
+						ID:        toolCall.ID,
+						Name:      toolCall.Name,
+						Arguments: toolCall.Arguments,
+						Iteration: iteration,
+						Sequence:  len(allToolCalls) + 1,
+						DependsOn: ordered.DependsOn,
 					})
 					continue
 				}
 
 				// Format and cache the result
-				resultContent = formatToolResult(mcpResult)
+				resultContent = s.processToolResult(toolCall.Name, toolCall.Arguments, mcpResult)
 				isError = mcpResult.IsError
-				
+				if isError {
+					toolErr = classifyToolResultError(resultContent)
+				}
+
 				// Store in cache (don't cache errors)
-				if !isError {
+				if cacheable && !isError {
 					s.resultCache.Set(cacheKey, resultContent, isError)
-					log.Printf("💾 Cached result for tool: %s", toolCall.Name)
+					executedReads[cacheKey] = resultContent
+					log.Printf("Cached result for tool: %s", toolCall.Name)
 				}
 			}
-			
+
+			if estimateNote := provisioningEstimateNote(blueprint); estimateNote != "" && !isError {
+				resultContent = estimateNote + "\n\n" + resultContent
+			}
+			if deprecation != "" && !isError {
+				resultContent = deprecation + " Warn the user and suggest a supported blueprint.\n\n" + resultContent
+			}
+			if blueprintNote != "" && !isError {
+				resultContent = blueprintNote + "\n\n" + resultContent
+			}
+			if contextNote != "" && !isError {
+				resultContent = contextNote + "\n\n" + resultContent
+			}
+
 			// Add to tool results
 			toolResults = append(toolResults, ai.ToolResult{
 				ToolCallID: toolCall.ID,
@@ -258,64 +667,222 @@ func (s *OrchestrationService) ProcessPrompt(ctx context.Context, request *model
 				ID:        toolCall.ID,
 				Name:      toolCall.Name,
 				Arguments: toolCall.Arguments,
+				Iteration: iteration,
+				Sequence:  len(allToolCalls) + 1,
+				DependsOn: ordered.DependsOn,
 			})
 
-			allToolResults = append(allToolResults, models.ToolResult{
-				ToolCallID: toolCall.ID,
-				Name:       toolCall.Name,
-				Content:    resultContent,
-				IsError:    isError,
-			})
+			toolResult := newToolResult(toolCall.ID, toolCall.Name, resultContent, isError, toolErr, iteration)
+			if blueprint != nil && !isError {
+				toolResult.EstimatedProvisioningSeconds = blueprint.EstimatedProvisioningSeconds
+				toolResult.DeprecationWarning = deprecation
+			}
+			allToolResults = append(allToolResults, toolResult)
 		}
 
 		// Add tool results to conversation history
-		conversationHistory = append(conversationHistory, ai.Message{
+		resultsMessage := ai.Message{
 			Role:        "assistant",
 			ToolResults: toolResults,
-		})
+		}
+		conversationHistory = append(conversationHistory, resultsMessage)
+		newMessages = append(newMessages, resultsMessage)
 
 		// Prepare next prompt with tool results
 		currentPrompt = formatToolResultsForPrompt(toolResults)
 	}
 
 	// If we hit max iterations, return what we have
+	s.saveConversation(ctx, request.SessionID, newMessages)
 	return &models.ChatResponse{
-		Response:    "Maximum tool execution iterations reached. Please try breaking down your request.",
-		ToolCalls:   allToolCalls,
-		ToolResults: allToolResults,
+		SessionID:             request.SessionID,
+		Response:              "Maximum tool execution iterations reached. Please try breaking down your request.",
+		ToolCalls:             allToolCalls,
+		ToolResults:           allToolResults,
+		PendingConfirmations:  pendingConfirmations,
+		IntermediateResponses: intermediateResponsesFor(request, intermediateResponses),
 		Metadata: map[string]interface{}{
-			"iterations":      iteration,
-			"max_reached":     true,
-			"provider":        s.aiProvider.GetProviderName(),
-			"tools_available": len(s.tools),
-			"cache_hits":      cacheHits,
-			"cache_misses":    cacheMisses,
-			"cache_stats":     s.resultCache.Stats(),
+			"iterations":                iteration,
+			"max_iterations":            maxIterations,
+			"max_iterations_configured": s.maxToolIterations,
+			"max_reached":               true,
+			"provider":                  aiProvider.GetProviderName(),
+			"tools_available":           len(tools),
+			"cache_hits":                cacheHits,
+			"cache_misses":              cacheMisses,
+			"cache_stats":               s.resultCache.Stats(),
+			"duplicate_tool_calls":      duplicateCalls,
+			"usage":                     usage,
+			"history_messages_dropped":  historyDropped,
+			"context_overflow_retries":  contextRetries,
+			"timings":                   timings.metadata(),
 		},
 	}, nil
 }
 
+// waitForProvider waits for the provider's rate limiter, adding the wait to timings
+func (s *OrchestrationService) waitForProvider(ctx context.Context, timings *phaseTimings, provider string) error {
+	start := time.Now()
+	defer func() { timings.rateLimit += time.Since(start) }()
+	return s.providerLimiter.Wait(ctx, provider)
+}
+
+// timedCallTool calls an MCP tool, adding the call's duration to timings
+func (s *OrchestrationService) timedCallTool(ctx context.Context, timings *phaseTimings, name string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	start := time.Now()
+	defer func() { timings.addTool(name, time.Since(start)) }()
+	return s.mcpClient.CallTool(ctx, name, arguments)
+}
+
+// saveConversation appends a request's messages to its session. A failed save is logged
+// rather than failing a response that has already been produced
+func (s *OrchestrationService) saveConversation(ctx context.Context, sessionID string, messages []ai.Message) {
+	if sessionID == "" || s.conversations == nil {
+		return
+	}
+	if err := s.conversations.AppendConversation(ctx, sessionID, messages); err != nil {
+		log.Printf("[trace %s] Failed to save session %s: %v", TraceIDFromContext(ctx), sessionID, err)
+	}
+}
+
 // GetAvailableTools returns the list of available MCP tools
 func (s *OrchestrationService) GetAvailableTools() []models.ToolInfo {
-	toolInfos := make([]models.ToolInfo, len(s.tools))
-	for i, tool := range s.tools {
-		// Type assert InputSchema to map[string]interface{}
-		var params map[string]interface{}
-		if tool.InputSchema != nil {
-			if schema, ok := tool.InputSchema.(map[string]interface{}); ok {
-				params = schema
-			}
-		}
-		
+	tools := s.currentTools()
+	toolInfos := make([]models.ToolInfo, len(tools))
+	for i, tool := range tools {
+		schema := toolSchema(tool)
 		toolInfos[i] = models.ToolInfo{
 			Name:        tool.Name,
 			Description: tool.Description,
-			Parameters:  params,
+			Parameters:  parseToolParameters(schema),
+			InputSchema: schema,
 		}
 	}
 	return toolInfos
 }
 
+// MatchBlueprint fetches the blueprints from the MCP server and returns those that
+// provide the requested service (see MatchBlueprint), marking deprecated ones
+func (s *OrchestrationService) MatchBlueprint(ctx context.Context, query string) ([]models.BlueprintMatch, error) {
+	data, err := s.fetchBlueprints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := MatchBlueprint(query, blueprintNames(data))
+	annotateDeprecatedMatches(matches, blueprintExports(data))
+	return matches, nil
+}
+
+// ListMCPResources returns the readable resources published by the MCP server
+func (s *OrchestrationService) ListMCPResources(ctx context.Context) ([]models.MCPResourceInfo, error) {
+	resources, err := s.mcpClient.ListResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]models.MCPResourceInfo, len(resources))
+	for i, r := range resources {
+		infos[i] = models.MCPResourceInfo{
+			URI:         r.URI,
+			Name:        r.Name,
+			Description: r.Description,
+			MIMEType:    r.MIMEType,
+		}
+	}
+	return infos, nil
+}
+
+// ReadMCPResource returns the contents of an MCP resource
+func (s *OrchestrationService) ReadMCPResource(ctx context.Context, uri string) ([]models.MCPResourceContent, error) {
+	result, err := s.mcpClient.ReadResource(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]models.MCPResourceContent, 0, len(result.Contents))
+	for _, c := range result.Contents {
+		if c == nil {
+			continue
+		}
+		contents = append(contents, models.MCPResourceContent{
+			URI:      c.URI,
+			MIMEType: c.MIMEType,
+			Text:     c.Text,
+			Blob:     c.Blob,
+		})
+	}
+	return contents, nil
+}
+
+// ListMCPPrompts returns the prompt templates published by the MCP server
+func (s *OrchestrationService) ListMCPPrompts(ctx context.Context) ([]models.MCPPromptInfo, error) {
+	prompts, err := s.mcpClient.ListPrompts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]models.MCPPromptInfo, len(prompts))
+	for i, p := range prompts {
+		args := make([]models.MCPPromptArgument, 0, len(p.Arguments))
+		for _, a := range p.Arguments {
+			args = append(args, models.MCPPromptArgument{
+				Name:        a.Name,
+				Description: a.Description,
+				Required:    a.Required,
+			})
+		}
+		infos[i] = models.MCPPromptInfo{
+			Name:        p.Name,
+			Description: p.Description,
+			Arguments:   args,
+		}
+	}
+	return infos, nil
+}
+
+// GetMCPPrompt renders an MCP prompt template with the given arguments
+func (s *OrchestrationService) GetMCPPrompt(ctx context.Context, name string, arguments map[string]string) (*models.MCPPromptResponse, error) {
+	result, err := s.mcpClient.GetPrompt(ctx, name, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]models.MCPPromptMessage, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		messages = append(messages, models.MCPPromptMessage{
+			Role:    string(m.Role),
+			Content: describeContent(m.Content),
+		})
+	}
+	return &models.MCPPromptResponse{
+		Description: result.Description,
+		Messages:    messages,
+	}, nil
+}
+
+// Close releases the AI providers held by the service and stops the result cache
+func (s *OrchestrationService) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.resultCache.Close()
+	errs := []error{closeProviders(s.providers)}
+	if err := s.modelProviders.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// closeProviders closes every provider, joining their errors
+func closeProviders(providers map[string]ai.Provider) error {
+	var errs []error
+	for name, provider := range providers {
+		if err := provider.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // HealthCheck checks the health of MCP and AI services
 func (s *OrchestrationService) HealthCheck(ctx context.Context) map[string]string {
 	status := make(map[string]string)
@@ -328,50 +895,130 @@ func (s *OrchestrationService) HealthCheck(ctx context.Context) map[string]strin
 	}
 
 	// Check AI provider
-	if s.aiProvider != nil {
-		status["ai_provider"] = s.aiProvider.GetProviderName()
+	if provider, ok := s.providers[s.defaultProvider]; ok {
+		status["ai_provider"] = provider.GetProviderName()
 	} else {
 		status["ai_provider"] = "not configured"
 	}
+	status["ai_providers"] = strings.Join(s.ProviderNames(), ",")
+	status["ai_model_clients"] = fmt.Sprintf("%d", s.modelProviders.Len())
+	for provider, stats := range s.providerLimiter.Stats() {
+		status["ai_rate_limit_"+provider] = fmt.Sprintf("%d/%d per minute used", stats["used"], stats["limit_per_minute"])
+	}
+
+	if s.inMaintenance() {
+		status["maintenance"] = "on"
+	} else {
+		status["maintenance"] = "off"
+	}
 
 	// Check tools
-	status["tools_count"] = fmt.Sprintf("%d", len(s.tools))
+	status["tools_count"] = fmt.Sprintf("%d", len(s.currentTools()))
 
 	return status
 }
 
+// generationConfigFor converts the request's sampling parameters for the AI provider
+func generationConfigFor(request *models.ChatRequest) *ai.GenerationConfig {
+	if request.GenerationConfig == nil {
+		return nil
+	}
+	return &ai.GenerationConfig{
+		Temperature: request.GenerationConfig.Temperature,
+		TopP:        request.GenerationConfig.TopP,
+		TopK:        request.GenerationConfig.TopK,
+		MaxTokens:   request.GenerationConfig.MaxTokens,
+	}
+}
+
 // formatToolResult formats the MCP tool result into a string
 func formatToolResult(result *mcp.CallToolResult) string {
-	if len(result.Content) == 0 {
+	if len(result.Content) == 0 && result.StructuredContent == nil {
 		return "Tool executed successfully with no output"
 	}
 
 	var textParts []string
+	mixed := false
 	for _, content := range result.Content {
-		// Use type assertion to extract text from Content interface
-		if textContent, ok := content.(*mcp.TextContent); ok {
-			textParts = append(textParts, textContent.Text)
+		if part := describeContent(content); part != "" {
+			textParts = append(textParts, part)
+		}
+		if _, isText := content.(*mcp.TextContent); !isText {
+			mixed = true
 		}
 	}
 
 	if len(textParts) == 0 {
-		// Try to marshal the whole result as JSON
-		jsonBytes, err := json.Marshal(result)
-		if err != nil {
-			return "Tool result could not be formatted"
+		// Prefer the structured output, then the whole result, as JSON
+		if result.StructuredContent != nil {
+			if jsonBytes, err := json.Marshal(result.StructuredContent); err == nil {
+				return string(jsonBytes)
+			}
 		}
-		return string(jsonBytes)
+		if jsonBytes, err := json.Marshal(result); err == nil {
+			return string(jsonBytes)
+		}
+		return fmt.Sprintf("Tool returned %d content item(s) that could not be formatted", len(result.Content))
 	}
 
 	if len(textParts) == 1 {
 		return textParts[0]
 	}
 
+	// Text mixed with images or resources reads best in order, one part per paragraph
+	if mixed {
+		return strings.Join(textParts, "\n\n")
+	}
+
 	// Multiple text parts, join them
 	resultBytes, _ := json.MarshalIndent(textParts, "", "  ")
 	return string(resultBytes)
 }
 
+// describeContent renders a single MCP content item as text the model can read
+func describeContent(content mcp.Content) string {
+	switch c := content.(type) {
+	case *mcp.TextContent:
+		return c.Text
+	case *mcp.ImageContent:
+		return fmt.Sprintf("[image: %s, %d bytes]", c.MIMEType, len(c.Data))
+	case *mcp.AudioContent:
+		return fmt.Sprintf("[audio: %s, %d bytes]", c.MIMEType, len(c.Data))
+	case *mcp.EmbeddedResource:
+		if c.Resource == nil {
+			return "[Embedded resource]"
+		}
+		if c.Resource.Text != "" {
+			return c.Resource.Text
+		}
+		if len(c.Resource.Blob) > 0 {
+			return fmt.Sprintf("[Embedded resource: %s (%s), %d bytes]", c.Resource.URI, c.Resource.MIMEType, len(c.Resource.Blob))
+		}
+		return fmt.Sprintf("[Embedded resource: %s (%s)]", c.Resource.URI, c.Resource.MIMEType)
+	case *mcp.ResourceLink:
+		if c.Name != "" {
+			return fmt.Sprintf("[Resource link: %s - %s]", c.Name, c.URI)
+		}
+		return fmt.Sprintf("[Resource link: %s]", c.URI)
+	default:
+		return ""
+	}
+}
+
+// narrativeText returns the prose part of an assistant message, dropping the
+// tool-call markup that text-based providers embed in their content
+func narrativeText(content string) string {
+	return ai.StripToolCalls(content)
+}
+
+// intermediateResponsesFor returns the intermediate narratives if the request asked for them
+func intermediateResponsesFor(request *models.ChatRequest, narratives []string) []string {
+	if !request.IncludeIntermediate {
+		return nil
+	}
+	return narratives
+}
+
 // formatToolResultsForPrompt formats tool results for the next AI prompt
 func formatToolResultsForPrompt(results []ai.ToolResult) string {
 	if len(results) == 0 {
@@ -381,9 +1028,9 @@ func formatToolResultsForPrompt(results []ai.ToolResult) string {
 	prompt := "Tool execution results:\n\n"
 	for _, result := range results {
 		if result.IsError {
-			prompt += fmt.Sprintf("❌ Error: %s\n\n", result.Content)
+			prompt += fmt.Sprintf("[ERROR] %s\n\n", result.Content)
 		} else {
-			prompt += fmt.Sprintf("✓ Success: %s\n\n", result.Content)
+			prompt += fmt.Sprintf("[OK] %s\n\n", result.Content)
 		}
 	}
 
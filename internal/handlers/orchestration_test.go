@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestProcessPromptPassesGenerationConfig(t *testing.T) {
+	temperature, topK := float32(0), int32(8)
+	tests := []struct {
+		name      string
+		genConfig *models.GenerationConfig
+		want      *ai.GenerationConfig
+	}{
+		{"unset keeps provider defaults", nil, nil},
+		{"overrides reach the provider",
+			&models.GenerationConfig{Temperature: &temperature, TopK: &topK},
+			&ai.GenerationConfig{Temperature: &temperature, TopK: &topK}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newFakeProvider("fake", textReply("done"))
+			service := newTestService(t, newTestMCPServer(t), provider, 5, time.Minute)
+
+			request := &models.ChatRequest{Prompt: "hi", GenerationConfig: tt.genConfig}
+			if _, err := service.ProcessPrompt(context.Background(), request); err != nil {
+				t.Fatalf("ProcessPrompt: %v", err)
+			}
+			calls := provider.chatCalls()
+			if len(calls) != 1 {
+				t.Fatalf("got %d Chat calls, want 1", len(calls))
+			}
+			if !reflect.DeepEqual(calls[0].config, tt.want) {
+				t.Errorf("Chat got config %+v, want %+v", calls[0].config, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessPromptSumsUsageAcrossIterations(t *testing.T) {
+	withUsage := func(reply fakeReply, usage *ai.Usage) fakeReply {
+		reply.response.Usage = usage
+		return reply
+	}
+	provider := newFakeProvider("fake",
+		withUsage(toolCallReply(ai.ToolCall{ID: "1", Name: "get_blueprints", Arguments: map[string]interface{}{}}),
+			&ai.Usage{PromptTokens: 100, CompletionTokens: 10, TotalTokens: 110}),
+		// Providers that don't report usage contribute nothing
+		toolCallReply(ai.ToolCall{ID: "2", Name: "get_blueprints", Arguments: map[string]interface{}{"page": 2}}),
+		withUsage(textReply("done"), &ai.Usage{PromptTokens: 150, CompletionTokens: 20, TotalTokens: 170, ReasoningTokens: 5}),
+	)
+	service := newTestService(t, newTestMCPServer(t), provider, 5, time.Minute)
+
+	resp := runPrompt(t, service, "list blueprints")
+
+	want := ai.Usage{PromptTokens: 250, CompletionTokens: 30, TotalTokens: 280, ReasoningTokens: 5}
+	if got := resp.Metadata["usage"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("usage = %+v, want %+v", got, want)
+	}
+}
+
+func TestCloseClosesEveryProvider(t *testing.T) {
+	built := make(map[string]*fakeProvider)
+	factory := func(name string) ai.ProviderFactory {
+		return func(model string) (ai.Provider, error) {
+			provider := newFakeProvider(name)
+			built[name+"/"+model] = provider
+			return provider, nil
+		}
+	}
+	client, err := mcp.NewClient(newTestMCPServer(t).URL, nil, mcp.ClientOptions{})
+	if err != nil {
+		t.Fatalf("mcp.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	factories := map[string]ai.ProviderFactory{"openai": factory("openai"), "gemini": factory("gemini")}
+	service, err := NewOrchestrationService(client, factories, "openai", 5, time.Minute)
+	if err != nil {
+		t.Fatalf("NewOrchestrationService: %v", err)
+	}
+	_, release, err := service.selectProvider("gemini", "gemini-1.5-flash")
+	if err != nil {
+		t.Fatalf("selectProvider: %v", err)
+	}
+	release()
+
+	if err := service.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for _, key := range []string{"openai/", "gemini/", "gemini/gemini-1.5-flash"} {
+		if provider, ok := built[key]; !ok || !provider.isClosed() {
+			t.Errorf("provider %s was not closed on shutdown", key)
+		}
+	}
+}
+
+func TestNewOrchestrationServiceClosesProvidersOnFailure(t *testing.T) {
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	client, err := mcp.NewClient(unreachable.URL, nil, mcp.ClientOptions{})
+	if err != nil {
+		t.Fatalf("mcp.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	provider := newFakeProvider("openai")
+	factories := map[string]ai.ProviderFactory{"openai": func(string) (ai.Provider, error) { return provider, nil }}
+	if _, err := NewOrchestrationService(client, factories, "openai", 5, time.Minute); err == nil {
+		t.Fatal("NewOrchestrationService succeeded without an MCP server")
+	}
+	if !provider.isClosed() {
+		t.Error("provider was not closed after setup failed")
+	}
+}
+
+func TestGenerateCacheKey(t *testing.T) {
+	type call struct {
+		provider, tool string
+		args           map[string]interface{}
+	}
+	args := map[string]interface{}{"name": "db", "replicas": 2}
+	base := call{"openai", "get_resource", args}
+	tests := []struct {
+		name      string
+		other     call
+		wantEqual bool
+	}{
+		{"same call", call{"openai", "get_resource", map[string]interface{}{"replicas": 2, "name": "db"}}, true},
+		{"different provider", call{"gemini", "get_resource", args}, false},
+		{"different tool", call{"openai", "list_resources", args}, false},
+		{"different args", call{"openai", "get_resource", map[string]interface{}{"name": "cache"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := generateCacheKey(base.provider, base.tool, base.args)
+			b := generateCacheKey(tt.other.provider, tt.other.tool, tt.other.args)
+			if (a == b) != tt.wantEqual {
+				t.Errorf("keys %q and %q: equal = %v, want %v", a, b, a == b, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestProcessPromptStopsAtMaxIterations(t *testing.T) {
+	call := func(id string) fakeReply {
+		return toolCallReply(ai.ToolCall{ID: id, Name: "get_blueprints", Arguments: map[string]interface{}{"page": id}})
+	}
+	provider := newFakeProvider("fake", call("1"), call("2"), call("3"))
+	service := newTestService(t, newTestMCPServer(t), provider, 2, time.Minute)
+
+	resp := runPrompt(t, service, "loop")
+	if resp.Metadata["max_reached"] != true || resp.Metadata["iterations"] != 2 {
+		t.Errorf("metadata = %v, want max_reached after 2 iterations", resp.Metadata)
+	}
+	if got := len(provider.chatCalls()); got != 2 {
+		t.Errorf("provider called %d times, want 2", got)
+	}
+}
+
+func TestResultCacheTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		wantRuns int
+	}{
+		{"zero TTL disables caching", 0, 2},
+		{"positive TTL caches", time.Minute, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestMCPServer(t)
+			call := ai.ToolCall{ID: "1", Name: "get_blueprints", Arguments: map[string]interface{}{}}
+			provider := newFakeProvider("fake", toolCallReply(call), textReply("done"), toolCallReply(call), textReply("done"))
+			service := newTestService(t, server, provider, 5, tt.ttl)
+
+			runPrompt(t, service, "list blueprints")
+			runPrompt(t, service, "list blueprints")
+			if got := server.callCount("get_blueprints"); got != tt.wantRuns {
+				t.Errorf("get_blueprints ran %d times, want %d", got, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestResultCacheCloseStopsCleanup(t *testing.T) {
+	before := runtime.NumGoroutine()
+	caches := make([]*ResultCache, 10)
+	for i := range caches {
+		caches[i] = NewResultCache(time.Minute)
+	}
+	if got := runtime.NumGoroutine(); got < before+len(caches) {
+		t.Fatalf("%d goroutines after starting %d caches, want at least %d", got, len(caches), before+len(caches))
+	}
+
+	for _, cache := range caches {
+		cache.Close()
+		cache.Close() // Close is idempotent
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > before {
+		t.Errorf("%d goroutines after Close, want at most %d", got, before)
+	}
+}
+
+func TestFormatToolResultsForPromptIsClean(t *testing.T) {
+	prompt := formatToolResultsForPrompt([]ai.ToolResult{
+		{ToolCallID: "1", Content: "created db"},
+		{ToolCallID: "2", Content: "quota exceeded", IsError: true},
+	})
+
+	if !utf8.ValidString(prompt) || strings.ContainsRune(prompt, utf8.RuneError) {
+		t.Errorf("prompt has invalid or replacement runes: %q", prompt)
+	}
+	for _, mojibake := range []string{"â", "ð", "Ÿ"} {
+		if strings.Contains(prompt, mojibake) {
+			t.Errorf("prompt contains mojibake %q: %q", mojibake, prompt)
+		}
+	}
+	if !strings.Contains(prompt, "[OK] created db") || !strings.Contains(prompt, "[ERROR] quota exceeded") {
+		t.Errorf("prompt lacks status markers: %q", prompt)
+	}
+}
+
+func TestProcessPromptRecordsToolTimeoutUncached(t *testing.T) {
+	server := newTestMCPServer(t, testTool{name: "get_status", handler: func(args map[string]any) (*sdkmcp.CallToolResult, error) {
+		time.Sleep(500 * time.Millisecond)
+		return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: "late"}}}, nil
+	}})
+	call := ai.ToolCall{ID: "1", Name: "get_status", Arguments: map[string]interface{}{}}
+	provider := newFakeProvider("fake", toolCallReply(call), textReply("timed out"), toolCallReply(call), textReply("timed out"))
+	service := newTestServiceWithOptions(t, server, mcp.ClientOptions{CallTimeout: 50 * time.Millisecond}, provider, 5, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		resp := runPrompt(t, service, "status?")
+		if len(resp.ToolResults) != 1 {
+			t.Fatalf("got %d tool results, want 1", len(resp.ToolResults))
+		}
+		result := resp.ToolResults[0]
+		if !result.IsError || result.ErrorType != string(ToolErrorTimeout) {
+			t.Errorf("tool result = %+v, want a timeout error", result)
+		}
+	}
+	// A timed-out call isn't cached, so the second request calls the tool again
+	if got := server.callCount("get_status"); got != 2 {
+		t.Errorf("get_status ran %d times, want 2", got)
+	}
+}
+
+func TestFormatToolResult(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0, 0, 0, 0}
+	tests := []struct {
+		name   string
+		result *mcp.CallToolResult
+		want   string
+	}{
+		{"empty", &mcp.CallToolResult{}, "Tool executed successfully with no output"},
+		{"single text", &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "created"}}}, "created"},
+		{"multiple texts", &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "a"}, &mcp.TextContent{Text: "b"}}}, "[\n  \"a\",\n  \"b\"\n]"},
+		{"text and image", &mcp.CallToolResult{Content: []mcp.Content{
+			&mcp.TextContent{Text: "Architecture diagram:"},
+			&mcp.ImageContent{MIMEType: "image/png", Data: png},
+		}}, "Architecture diagram:\n\n[image: image/png, 8 bytes]"},
+		{"audio", &mcp.CallToolResult{Content: []mcp.Content{&mcp.AudioContent{MIMEType: "audio/wav", Data: []byte("RIFF")}}}, "[audio: audio/wav, 4 bytes]"},
+		{"resources", &mcp.CallToolResult{Content: []mcp.Content{
+			&mcp.EmbeddedResource{Resource: &sdkmcp.ResourceContents{URI: "file:///plan.txt", MIMEType: "text/plain", Text: "plan"}},
+			&mcp.EmbeddedResource{Resource: &sdkmcp.ResourceContents{URI: "file:///state.bin", MIMEType: "application/octet-stream", Blob: []byte{1, 2, 3}}},
+			&mcp.ResourceLink{Name: "logs", URI: "https://logs.example.com/db"},
+		}}, "plan\n\n[Embedded resource: file:///state.bin (application/octet-stream), 3 bytes]\n\n[Resource link: logs - https://logs.example.com/db]"},
+		{"structured only", &mcp.CallToolResult{StructuredContent: map[string]any{"ok": true}}, `{"ok":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatToolResult(tt.result); got != tt.want {
+				t.Errorf("formatToolResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+// MaxModelProviders caps how many model-override clients are kept, since model names come from
+// requests. The least recently used client is evicted to make room for a new one
+const MaxModelProviders = 16
+
+// ErrProviderUnavailable is returned when a request names a provider that isn't configured
+var ErrProviderUnavailable = errors.New("AI provider not available")
+
+// initProviders constructs every configured provider with its default model.
+// The default provider must initialize; other providers that fail are logged and skipped
+func initProviders(factories map[string]ai.ProviderFactory, defaultProvider string) (map[string]ai.Provider, error) {
+	if _, ok := factories[defaultProvider]; !ok {
+		return nil, fmt.Errorf("default AI provider %q is not configured", defaultProvider)
+	}
+
+	providers := make(map[string]ai.Provider, len(factories))
+	for name, factory := range factories {
+		provider, err := factory("")
+		if err != nil {
+			if name == defaultProvider {
+				return nil, fmt.Errorf("failed to initialize AI provider %s: %w", name, err)
+			}
+			log.Printf("Skipping AI provider %s: %v", name, err)
+			continue
+		}
+		providers[name] = provider
+	}
+
+	return providers, nil
+}
+
+// selectProvider returns the provider for a request: the one named in request.Provider
+// (or the default), or a cached client for request.Model when a model override is given.
+// The caller must call release once it has finished with the provider
+func (s *OrchestrationService) selectProvider(providerName, model string) (provider ai.Provider, release func(), err error) {
+	if providerName == "" {
+		providerName = s.defaultProvider
+	}
+
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q (available: %v)", ErrProviderUnavailable, providerName, s.ProviderNames())
+	}
+	if model == "" {
+		return provider, func() {}, nil
+	}
+
+	factory := s.providerFactories[providerName]
+	provider, release, err = s.modelProviders.Get(providerName+"/"+model, func() (ai.Provider, error) {
+		return factory(model)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s with model %q: %v", ErrProviderUnavailable, providerName, model, err)
+	}
+	return provider, release, nil
+}
+
+// ProviderNames returns the names of the initialized providers, sorted
+func (s *OrchestrationService) ProviderNames() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// providerCacheEntry is a provider client being built or ready for reuse
+type providerCacheEntry struct {
+	ready    chan struct{} // closed once provider/err are set
+	provider ai.Provider
+	err      error
+
+	// Guarded by ProviderCache.mu
+	lastUsed time.Time
+	inUse    int  // requests holding the provider
+	evicted  bool // removed from the cache; closed once no request holds it
+}
+
+// isReady reports whether the entry has finished building
+func (e *providerCacheEntry) isReady() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProviderCache lazily builds and reuses provider clients keyed by provider and model.
+// Concurrent first requests for the same key share a single construction. Once full, the
+// least recently used client is evicted, and closed when the last request using it finishes
+type ProviderCache struct {
+	entries    map[string]*providerCacheEntry
+	mu         sync.Mutex
+	maxEntries int
+}
+
+// NewProviderCache creates an empty provider cache holding at most maxEntries providers
+func NewProviderCache(maxEntries int) *ProviderCache {
+	return &ProviderCache{
+		entries:    make(map[string]*providerCacheEntry),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get returns the cached provider for key, calling build once if none exists yet, and a release
+// func the caller must call when done with it. Failed builds aren't cached, so a later request
+// retries. New keys are refused only while every cached client is still being built
+func (c *ProviderCache) Get(key string, build func() (ai.Provider, error)) (ai.Provider, func(), error) {
+	c.mu.Lock()
+	entry, exists := c.entries[key]
+	var evicted *providerCacheEntry
+	if !exists {
+		if len(c.entries) >= c.maxEntries {
+			var ok bool
+			if evicted, ok = c.evictLocked(); !ok {
+				c.mu.Unlock()
+				return nil, nil, fmt.Errorf("too many model clients (limit %d)", c.maxEntries)
+			}
+		}
+		entry = &providerCacheEntry{ready: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	entry.inUse++
+	entry.lastUsed = time.Now()
+	c.mu.Unlock()
+
+	if evicted != nil {
+		closeEvictedProvider(evicted)
+	}
+
+	if exists {
+		<-entry.ready
+	} else {
+		entry.provider, entry.err = build()
+		if entry.err != nil {
+			c.mu.Lock()
+			if c.entries[key] == entry {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+		} else {
+			log.Printf("Initialized AI provider client: %s", key)
+		}
+		close(entry.ready)
+	}
+
+	var once sync.Once
+	release := func() { once.Do(func() { c.release(entry) }) }
+	if entry.err != nil {
+		release()
+		return nil, nil, entry.err
+	}
+	return entry.provider, release, nil
+}
+
+// evictLocked removes the least recently used built client from the cache, reporting false if
+// every client is still being built. The evicted client is returned for the caller to close
+// outside the lock, unless a request still holds it; the last release closes it then
+func (c *ProviderCache) evictLocked() (toClose *providerCacheEntry, ok bool) {
+	var oldestKey string
+	var oldest *providerCacheEntry
+	for key, entry := range c.entries {
+		if !entry.isReady() {
+			continue
+		}
+		if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+			oldestKey, oldest = key, entry
+		}
+	}
+	if oldest == nil {
+		return nil, false
+	}
+
+	delete(c.entries, oldestKey)
+	oldest.evicted = true
+	log.Printf("Evicted least recently used AI provider client: %s", oldestKey)
+	if oldest.inUse > 0 {
+		return nil, true
+	}
+	return oldest, true
+}
+
+// release marks one request as done with entry, closing it if it was evicted meanwhile
+func (c *ProviderCache) release(entry *providerCacheEntry) {
+	c.mu.Lock()
+	entry.inUse--
+	closeNow := entry.evicted && entry.inUse == 0
+	c.mu.Unlock()
+
+	if closeNow {
+		closeEvictedProvider(entry)
+	}
+}
+
+// closeEvictedProvider closes an evicted client, logging rather than returning any error
+func closeEvictedProvider(entry *providerCacheEntry) {
+	if entry.provider == nil || entry.err != nil {
+		return
+	}
+	if err := entry.provider.Close(); err != nil {
+		log.Printf("Failed to close evicted AI provider client: %v", err)
+	}
+}
+
+// Close closes every cached provider and empties the cache. Providers still held by a request
+// are closed when it releases them
+func (c *ProviderCache) Close() error {
+	c.mu.Lock()
+	entries := c.entries
+	c.entries = make(map[string]*providerCacheEntry)
+	c.mu.Unlock()
+
+	var errs []error
+	for key, entry := range entries {
+		<-entry.ready
+		c.mu.Lock()
+		entry.evicted = true
+		held := entry.inUse > 0
+		c.mu.Unlock()
+		if entry.err != nil || held {
+			continue
+		}
+		if err := entry.provider.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Len returns the number of cached providers
+func (c *ProviderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
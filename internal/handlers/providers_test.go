@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// buildFake returns a build func that records the providers it creates
+func buildFake(built map[string]*fakeProvider, key string) func() (ai.Provider, error) {
+	return func() (ai.Provider, error) {
+		provider := newFakeProvider(key)
+		built[key] = provider
+		return provider, nil
+	}
+}
+
+func TestProviderCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewProviderCache(2)
+	built := make(map[string]*fakeProvider)
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		_, release, err := cache.Get(key, buildFake(built, key))
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		release()
+	}
+
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	if !built["b"].isClosed() {
+		t.Error("least recently used client b was not closed on eviction")
+	}
+	if built["a"].isClosed() || built["c"].isClosed() {
+		t.Error("recently used clients were closed")
+	}
+}
+
+func TestProviderCacheClosesHeldClientOnRelease(t *testing.T) {
+	cache := NewProviderCache(1)
+	built := make(map[string]*fakeProvider)
+
+	_, releaseA, err := cache.Get("a", buildFake(built, "a"))
+	if err != nil {
+		t.Fatalf("Get(a): %v", err)
+	}
+	// A flood of new model names can't lock out new clients or close one mid-request
+	for _, key := range []string{"bogus-1", "bogus-2", "bogus-3"} {
+		_, release, err := cache.Get(key, buildFake(built, key))
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		release()
+	}
+	if built["a"].isClosed() {
+		t.Fatal("evicted client a was closed while still in use")
+	}
+
+	releaseA()
+	releaseA() // release is idempotent
+	if !built["a"].isClosed() {
+		t.Error("evicted client a was not closed after its last release")
+	}
+}
+
+func TestProviderCacheBuildsOncePerKey(t *testing.T) {
+	cache := NewProviderCache(4)
+	var builds int32
+	unblock := make(chan struct{})
+	build := func() (ai.Provider, error) {
+		atomic.AddInt32(&builds, 1)
+		<-unblock
+		return newFakeProvider("shared"), nil
+	}
+
+	const requests = 8
+	var wg sync.WaitGroup
+	got := make([]ai.Provider, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			provider, release, err := cache.Get("openai/gpt-4o", build)
+			if err != nil {
+				t.Errorf("Get: %v", err)
+				return
+			}
+			defer release()
+			got[i] = provider
+		}(i)
+	}
+	close(unblock)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&builds); n != 1 {
+		t.Errorf("build ran %d times for one key, want 1", n)
+	}
+	for i := 1; i < requests; i++ {
+		if got[i] != got[0] {
+			t.Fatal("concurrent requests for one key got different providers")
+		}
+	}
+}
+
+func TestProviderCacheRefusesWhileFullOfPendingBuilds(t *testing.T) {
+	cache := NewProviderCache(1)
+	building := make(chan struct{})
+	unblock := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, release, err := cache.Get("slow", func() (ai.Provider, error) {
+			close(building)
+			<-unblock
+			return newFakeProvider("slow"), nil
+		})
+		if err == nil {
+			release()
+		}
+	}()
+	<-building
+
+	if _, _, err := cache.Get("other", buildFake(map[string]*fakeProvider{}, "other")); err == nil {
+		t.Error("Get succeeded with the cache full of pending builds, want an error")
+	}
+	close(unblock)
+	<-done
+}
+
+func TestProviderCacheDoesNotCacheFailedBuilds(t *testing.T) {
+	cache := NewProviderCache(2)
+	if _, _, err := cache.Get("bad", func() (ai.Provider, error) { return nil, errors.New("bad model") }); err == nil {
+		t.Fatal("Get returned no error for a failed build")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d after a failed build, want 0", cache.Len())
+	}
+
+	provider, release, err := cache.Get("bad", buildFake(map[string]*fakeProvider{}, "bad"))
+	if err != nil || provider == nil {
+		t.Fatalf("retry after a failed build: %v", err)
+	}
+	release()
+}
+
+func TestSelectProvider(t *testing.T) {
+	defaultProvider, other := newFakeProvider("openai"), newFakeProvider("anthropic")
+	var builtModels []string
+	factory := func(name string) ai.ProviderFactory {
+		return func(model string) (ai.Provider, error) {
+			builtModels = append(builtModels, name+"/"+model)
+			return newFakeProvider(name), nil
+		}
+	}
+	s := &OrchestrationService{
+		providers: map[string]ai.Provider{"openai": defaultProvider, "anthropic": other},
+		providerFactories: map[string]ai.ProviderFactory{
+			"openai":    factory("openai"),
+			"anthropic": factory("anthropic"),
+		},
+		modelProviders:  NewProviderCache(MaxModelProviders),
+		defaultProvider: "openai",
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		model    string
+		want     ai.Provider // nil when a model override builds a new client
+		wantErr  bool
+	}{
+		{"default", "", "", defaultProvider, false},
+		{"named", "anthropic", "", other, false},
+		{"model override", "anthropic", "claude-3-opus", nil, false},
+		{"unavailable", "mistral", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, release, err := s.selectProvider(tt.provider, tt.model)
+			if tt.wantErr {
+				if !errors.Is(err, ErrProviderUnavailable) {
+					t.Fatalf("selectProvider error = %v, want ErrProviderUnavailable", err)
+				}
+				if code := errorCodeFor(err); code.HTTPStatus() != http.StatusBadRequest {
+					t.Errorf("unavailable provider maps to HTTP %d, want 400", code.HTTPStatus())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectProvider: %v", err)
+			}
+			defer release()
+			if tt.want != nil && provider != tt.want {
+				t.Errorf("selectProvider(%q, %q) = %s, want %s", tt.provider, tt.model, provider.GetProviderName(), tt.want.GetProviderName())
+			}
+			if tt.want == nil && (provider == defaultProvider || provider == other) {
+				t.Error("model override returned the default-model client")
+			}
+		})
+	}
+	if len(builtModels) != 1 || builtModels[0] != "anthropic/claude-3-opus" {
+		t.Errorf("built model clients %v, want [anthropic/claude-3-opus]", builtModels)
+	}
+	if errorCodeFor(ErrProviderUnavailable) != models.ErrorCodeProviderUnavailable {
+		t.Errorf("errorCodeFor(ErrProviderUnavailable) = %s", errorCodeFor(ErrProviderUnavailable))
+	}
+}
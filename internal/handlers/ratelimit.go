@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+// ErrProviderRateLimited is returned when an AI provider's request budget is exhausted
+var ErrProviderRateLimited = errors.New("AI provider rate limit reached")
+
+// RateLimitError reports which provider is limited and when a token frees up
+type RateLimitError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v for %s, retry after %s", ErrProviderRateLimited, e.Provider, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrProviderRateLimited
+}
+
+// ProviderRateLimiter also meters the retries made inside ai.WithRetry
+var _ ai.RateLimiter = (*ProviderRateLimiter)(nil)
+
+// tokenBucket refills at limit tokens per minute, holding at most limit tokens
+type tokenBucket struct {
+	mu       sync.Mutex
+	limit    int
+	tokens   float64
+	perSec   float64
+	last     time.Time
+	waiting  int
+	rejected int
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit), b.tokens+now.Sub(b.last).Seconds()*b.perSec)
+	b.last = now
+}
+
+// ProviderRateLimiter caps AI provider calls per minute across all clients, per provider.
+// Calls over the limit wait up to maxWait for a token before being rejected.
+type ProviderRateLimiter struct {
+	buckets map[string]*tokenBucket
+	maxWait time.Duration
+}
+
+// NewProviderRateLimiter creates a limiter from provider name -> requests per minute.
+// Providers without a limit are not throttled
+func NewProviderRateLimiter(limits map[string]int, maxWait time.Duration) *ProviderRateLimiter {
+	limiter := &ProviderRateLimiter{
+		buckets: make(map[string]*tokenBucket, len(limits)),
+		maxWait: maxWait,
+	}
+	now := time.Now()
+	for provider, rpm := range limits {
+		if rpm <= 0 {
+			continue
+		}
+		limiter.buckets[provider] = &tokenBucket{
+			limit:  rpm,
+			tokens: float64(rpm),
+			perSec: float64(rpm) / 60,
+			last:   now,
+		}
+	}
+	return limiter
+}
+
+// Wait takes a token for the provider, waiting up to maxWait for one to refill.
+// It returns a *RateLimitError if no token would be available in time
+func (l *ProviderRateLimiter) Wait(ctx context.Context, provider string) error {
+	if l == nil {
+		return nil
+	}
+	bucket, ok := l.buckets[provider]
+	if !ok {
+		return nil
+	}
+
+	bucket.mu.Lock()
+	bucket.refill(time.Now())
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.mu.Unlock()
+		return nil
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / bucket.perSec * float64(time.Second))
+	if wait > l.maxWait {
+		bucket.rejected++
+		bucket.mu.Unlock()
+		return &RateLimitError{Provider: provider, RetryAfter: wait}
+	}
+
+	// Reserve the next token now so concurrent waiters queue behind each other
+	bucket.tokens--
+	bucket.waiting++
+	bucket.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		bucket.mu.Lock()
+		bucket.waiting--
+		bucket.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		bucket.mu.Lock()
+		bucket.waiting--
+		bucket.tokens++ // Give back the reserved token
+		bucket.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Stats returns per-provider usage against the limit
+func (l *ProviderRateLimiter) Stats() map[string]map[string]int {
+	stats := make(map[string]map[string]int)
+	if l == nil {
+		return stats
+	}
+	now := time.Now()
+	for provider, bucket := range l.buckets {
+		bucket.mu.Lock()
+		bucket.refill(now)
+		available := int(math.Max(0, math.Floor(bucket.tokens)))
+		stats[provider] = map[string]int{
+			"limit_per_minute": bucket.limit,
+			"available":        available,
+			"used":             bucket.limit - available,
+			"waiting":          bucket.waiting,
+			"rejected":         bucket.rejected,
+		}
+		bucket.mu.Unlock()
+	}
+	return stats
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProviderRateLimiterRejectsWithRetryAfter(t *testing.T) {
+	// 60 rpm refills one token a second, longer than the 10ms callers may wait
+	limiter := NewProviderRateLimiter(map[string]int{"openai": 60}, 10*time.Millisecond)
+	ctx := context.Background()
+
+	for i := 0; i < 60; i++ {
+		if err := limiter.Wait(ctx, "openai"); err != nil {
+			t.Fatalf("call %d within the limit: %v", i+1, err)
+		}
+	}
+
+	err := limiter.Wait(ctx, "openai")
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("Wait over the limit = %v, want *RateLimitError", err)
+	}
+	if !errors.Is(err, ErrProviderRateLimited) {
+		t.Error("RateLimitError doesn't unwrap to ErrProviderRateLimited")
+	}
+	if rateErr.Provider != "openai" || rateErr.RetryAfter <= 0 || rateErr.RetryAfter > time.Second {
+		t.Errorf("RateLimitError = %+v, want openai with RetryAfter in (0, 1s]", rateErr)
+	}
+
+	stats := limiter.Stats()["openai"]
+	if stats["rejected"] != 1 || stats["available"] != 0 || stats["limit_per_minute"] != 60 {
+		t.Errorf("Stats = %v", stats)
+	}
+}
+
+func TestProviderRateLimiterWaitsForRefill(t *testing.T) {
+	// 6000 rpm refills a token every 10ms, within the 1s callers may wait
+	limiter := NewProviderRateLimiter(map[string]int{"openai": 6000}, time.Second)
+	ctx := context.Background()
+	for i := 0; i < 6000; i++ {
+		if err := limiter.Wait(ctx, "openai"); err != nil {
+			t.Fatalf("call %d within the limit: %v", i+1, err)
+		}
+	}
+
+	if err := limiter.Wait(ctx, "openai"); err != nil {
+		t.Errorf("Wait for a refill within maxWait: %v", err)
+	}
+}
+
+func TestProviderRateLimiterWaitStopsOnCancel(t *testing.T) {
+	limiter := NewProviderRateLimiter(map[string]int{"openai": 1}, time.Hour)
+	if err := limiter.Wait(context.Background(), "openai"); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx, "openai"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait with a cancelled context = %v, want context.Canceled", err)
+	}
+	if got := limiter.Stats()["openai"]["waiting"]; got != 0 {
+		t.Errorf("waiting = %d after the cancelled call returned, want 0", got)
+	}
+}
+
+func TestProviderRateLimiterIgnoresUnlimitedProviders(t *testing.T) {
+	limiter := NewProviderRateLimiter(map[string]int{"openai": 1, "gemini": 0}, 0)
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background(), "gemini"); err != nil {
+			t.Fatalf("unlimited provider was throttled: %v", err)
+		}
+	}
+
+	var nilLimiter *ProviderRateLimiter
+	if err := nilLimiter.Wait(context.Background(), "openai"); err != nil {
+		t.Errorf("nil limiter throttled: %v", err)
+	}
+}
+
+func TestRateLimitWaitIsNotCountedAsAITime(t *testing.T) {
+	// 6000 rpm refills a token every 10ms, so the second call waits for a refill
+	service := &OrchestrationService{providerLimiter: NewProviderRateLimiter(map[string]int{"fake": 6000}, time.Second)}
+	timings := newPhaseTimings()
+	for i := 0; i < 6001; i++ {
+		if err := service.waitForProvider(context.Background(), timings, "fake"); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+
+	if timings.rateLimit <= 0 {
+		t.Error("rate-limit wait wasn't recorded")
+	}
+	if timings.ai != 0 {
+		t.Errorf("ai = %v, want the wait kept out of provider time", timings.ai)
+	}
+	if _, ok := timings.metadata()["rate_limit_ms"]; !ok {
+		t.Error("metadata has no rate_limit_ms")
+	}
+}
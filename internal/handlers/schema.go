@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"sort"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// toolSchema returns a tool's input schema as a map, or nil if it isn't a JSON object schema
+func toolSchema(tool *mcp.Tool) map[string]interface{} {
+	if tool.InputSchema == nil {
+		return nil
+	}
+	schema, ok := tool.InputSchema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return schema
+}
+
+// parseToolParameters flattens a JSON Schema object into a list of parameters sorted by name
+func parseToolParameters(schema map[string]interface{}) []models.ToolParameter {
+	params := []models.ToolParameter{}
+	if schema == nil {
+		return params
+	}
+
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return params
+	}
+
+	required := make(map[string]bool)
+	if requiredList, ok := schema["required"].([]interface{}); ok {
+		for _, r := range requiredList {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	for name, raw := range properties {
+		param := models.ToolParameter{
+			Name:     name,
+			Type:     "any",
+			Required: required[name],
+		}
+
+		if prop, ok := raw.(map[string]interface{}); ok {
+			switch t := prop["type"].(type) {
+			case string:
+				param.Type = t
+			case []interface{}:
+				// Nullable types are declared as ["string", "null"]; report the first non-null type
+				for _, item := range t {
+					if s, ok := item.(string); ok && s != "null" {
+						param.Type = s
+						break
+					}
+				}
+			}
+			if d, ok := prop["description"].(string); ok {
+				param.Description = d
+			}
+			if e, ok := prop["enum"].([]interface{}); ok {
+				param.Enum = e
+			}
+			if d, exists := prop["default"]; exists {
+				param.Default = d
+			}
+		}
+
+		params = append(params, param)
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+
+	return params
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import "time"
+
+// phaseTimings records where a chat request spent its time, so clients can tell whether
+// a slow response was due to the AI provider, a tool, or the orchestration loop itself
+type phaseTimings struct {
+	start     time.Time
+	ai        time.Duration            // provider Chat calls
+	rateLimit time.Duration            // waits for the provider rate limiter
+	tools     time.Duration            // MCP tool calls
+	cache     time.Duration            // result cache lookups
+	perTool   map[string]time.Duration // MCP call time summed per tool name
+}
+
+func newPhaseTimings() *phaseTimings {
+	return &phaseTimings{start: time.Now(), perTool: make(map[string]time.Duration)}
+}
+
+// addTool records the duration of one MCP call
+func (t *phaseTimings) addTool(name string, d time.Duration) {
+	t.tools += d
+	t.perTool[name] += d
+}
+
+// metadata renders the breakdown in milliseconds. loop_ms is whatever the other phases
+// don't account for: prompt building, history trimming, result hooks
+func (t *phaseTimings) metadata() map[string]interface{} {
+	total := time.Since(t.start)
+	perTool := make(map[string]int64, len(t.perTool))
+	for name, d := range t.perTool {
+		perTool[name] = d.Milliseconds()
+	}
+	return map[string]interface{}{
+		"total_ms":      total.Milliseconds(),
+		"ai_ms":         t.ai.Milliseconds(),
+		"rate_limit_ms": t.rateLimit.Milliseconds(),
+		"tools_ms":      t.tools.Milliseconds(),
+		"tool_ms":       perTool,
+		"cache_ms":      t.cache.Milliseconds(),
+		"loop_ms":       (total - t.ai - t.rateLimit - t.tools - t.cache).Milliseconds(),
+	}
+}
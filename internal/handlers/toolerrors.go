@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+)
+
+// Tool error types reported on failed tool results
+const (
+	ToolErrorConnection = "connection" // The MCP server couldn't be reached
+	ToolErrorTimeout    = "timeout"    // The call didn't complete in time
+	ToolErrorValidation = "validation" // The arguments were rejected
+	ToolErrorNotFound   = "not_found"  // The tool or the target it acts on doesn't exist
+	ToolErrorTransient  = "transient"  // A temporary failure reported by the server or tool
+	ToolErrorTool       = "tool_error" // Any other failure reported by the tool
+)
+
+// ToolError is the classification of a failed tool call
+type ToolError struct {
+	Type      string
+	Code      string
+	Retryable bool
+}
+
+// transientHints and validationHints are matched against error messages returned by
+// the MCP server and tools, which carry no machine-readable code
+var (
+	transientHints  = []string{"timeout", "timed out", "unavailable", "try again", "rate limit", "too many requests", "overloaded", "server is closing", "connection reset", "503", "429"}
+	validationHints = []string{"invalid params", "invalid", "required", "validation", "must be", "malformed"}
+	notFoundHints   = []string{"not found", "does not exist", "no such", "unknown tool"}
+)
+
+// classifyCallError classifies an error returned by the MCP client while calling a tool
+func classifyCallError(err error) ToolError {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, mcp.ErrNotConnected):
+		return ToolError{Type: ToolErrorConnection, Code: "mcp_not_connected", Retryable: true}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ToolError{Type: ToolErrorTimeout, Code: "mcp_timeout", Retryable: true}
+	case errors.As(err, &netErr):
+		return ToolError{Type: ToolErrorConnection, Code: "mcp_unreachable", Retryable: true}
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "method not found"), containsAny(message, notFoundHints):
+		return ToolError{Type: ToolErrorNotFound, Code: "tool_not_found", Retryable: false}
+	case strings.Contains(message, "invalid params"):
+		return ToolError{Type: ToolErrorValidation, Code: "invalid_arguments", Retryable: false}
+	case containsAny(message, transientHints):
+		return ToolError{Type: ToolErrorTransient, Code: "mcp_unavailable", Retryable: true}
+	default:
+		return ToolError{Type: ToolErrorConnection, Code: "mcp_error", Retryable: true}
+	}
+}
+
+// classifyToolResultError classifies a tool result the tool itself marked as an error
+func classifyToolResultError(content string) ToolError {
+	message := strings.ToLower(content)
+	switch {
+	case containsAny(message, transientHints):
+		return ToolError{Type: ToolErrorTransient, Code: "tool_unavailable", Retryable: true}
+	case containsAny(message, notFoundHints):
+		return ToolError{Type: ToolErrorNotFound, Code: "target_not_found", Retryable: false}
+	case containsAny(message, validationHints):
+		return ToolError{Type: ToolErrorValidation, Code: "invalid_arguments", Retryable: false}
+	default:
+		return ToolError{Type: ToolErrorTool, Code: "tool_failed", Retryable: false}
+	}
+}
+
+// newToolResult builds an API tool result for the given iteration, attaching the error
+// classification to failed calls
+func newToolResult(toolCallID, name, content string, isError bool, toolErr ToolError, iteration int) models.ToolResult {
+	result := models.ToolResult{
+		ToolCallID: toolCallID,
+		Name:       name,
+		Content:    content,
+		IsError:    isError,
+		Iteration:  iteration,
+	}
+	if isError {
+		result.ErrorType = toolErr.Type
+		result.ErrorCode = toolErr.Code
+		result.Retryable = toolErr.Retryable
+	}
+	return result
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
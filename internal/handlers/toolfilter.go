@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// ToolFilter restricts which MCP tools the AI may see and call. An empty allowlist allows
+// every tool; a tool on the denylist is never allowed, even if it is also allowlisted
+type ToolFilter struct {
+	allowed map[string]bool
+	denied  map[string]bool
+}
+
+// NewToolFilter creates a filter from allowed and denied tool names
+func NewToolFilter(allowed, denied []string) *ToolFilter {
+	f := &ToolFilter{
+		allowed: make(map[string]bool, len(allowed)),
+		denied:  make(map[string]bool, len(denied)),
+	}
+	for _, name := range allowed {
+		f.allowed[name] = true
+	}
+	for _, name := range denied {
+		f.denied[name] = true
+	}
+	return f
+}
+
+// Allows reports whether the AI may call the named tool. A nil filter allows everything
+func (f *ToolFilter) Allows(name string) bool {
+	if f == nil {
+		return true
+	}
+	if f.denied[name] {
+		return false
+	}
+	return len(f.allowed) == 0 || f.allowed[name]
+}
+
+// Filter returns the allowed tools, preserving order
+func (f *ToolFilter) Filter(tools []*mcp.Tool) []*mcp.Tool {
+	filtered := make([]*mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if f.Allows(tool.Name) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// notAllowedResult is the tool result returned when the AI calls a filtered-out tool
+func notAllowedResult(name string) (string, ToolError) {
+	return fmt.Sprintf("Tool %s is not available in this deployment and was NOT executed. Use only the tools you were given.", name),
+		ToolError{Type: ToolErrorValidation, Code: "tool_not_allowed", Retryable: false}
+}
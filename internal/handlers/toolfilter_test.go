@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+func TestToolFilter(t *testing.T) {
+	tools := []*mcp.Tool{{Name: "get_blueprints"}, {Name: "create_resource"}, {Name: "delete_resource"}}
+	tests := []struct {
+		name    string
+		allowed []string
+		denied  []string
+		want    []string
+	}{
+		{"no filter", nil, nil, []string{"get_blueprints", "create_resource", "delete_resource"}},
+		{"allow only", []string{"get_blueprints", "create_resource"}, nil, []string{"get_blueprints", "create_resource"}},
+		{"deny only", nil, []string{"delete_resource"}, []string{"get_blueprints", "create_resource"}},
+		{"deny wins over allow", []string{"get_blueprints", "delete_resource"}, []string{"delete_resource"}, []string{"get_blueprints"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tool := range NewToolFilter(tt.allowed, tt.denied).Filter(tools) {
+				got = append(got, tool.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var nilFilter *ToolFilter
+	if !nilFilter.Allows("delete_resource") {
+		t.Error("nil filter rejected a tool")
+	}
+}
+
+func TestProcessPromptHidesAndRejectsFilteredTools(t *testing.T) {
+	server := newTestMCPServer(t)
+	provider := newFakeProvider("fake",
+		// A model may still name a tool it wasn't given
+		toolCallReply(ai.ToolCall{ID: "1", Name: "delete_resource", Arguments: map[string]interface{}{"name": "db"}}),
+		textReply("done"),
+	)
+	service := newTestService(t, server, provider, 5, time.Minute)
+	service.SetToolFilter(NewToolFilter(nil, []string{"delete_resource"}))
+
+	resp := runPrompt(t, service, "delete db")
+
+	for _, tool := range provider.chatCalls()[0].tools {
+		if tool.Name == "delete_resource" {
+			t.Error("the AI was offered a denied tool")
+		}
+	}
+	if got := server.callCount("delete_resource"); got != 0 {
+		t.Errorf("denied tool ran %d times, want 0", got)
+	}
+	if len(resp.ToolResults) != 1 || !resp.ToolResults[0].IsError || resp.ToolResults[0].ErrorCode != "tool_not_allowed" {
+		t.Errorf("tool results = %+v, want one tool_not_allowed error", resp.ToolResults)
+	}
+	for _, info := range service.GetAvailableTools() {
+		if info.Name == "delete_resource" {
+			t.Error("GetAvailableTools lists a denied tool")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+)
+
+// createdNameArgs are the arguments that name the resource a create call makes
+var createdNameArgs = []string{"name", "resource_name", "resourceName"}
+
+// orderedToolCall is a tool call with the IDs of calls in its batch that must run first
+type orderedToolCall struct {
+	Call      ai.ToolCall
+	DependsOn []string
+}
+
+// orderToolCalls puts a batch of tool calls in a safe execution order. A call whose
+// arguments mention the name of a resource created by another call in the batch (e.g. an
+// app referencing its database) runs after that create. Otherwise, and for calls caught in
+// a dependency cycle, the model's order is kept
+func orderToolCalls(calls []ai.ToolCall) []orderedToolCall {
+	// created[i] is the resource name call i creates, if it's a create call
+	created := make([]string, len(calls))
+	for i, call := range calls {
+		if strings.Contains(strings.ToLower(call.Name), "create") {
+			created[i] = createdName(call.Arguments)
+		}
+	}
+
+	deps := make([][]int, len(calls))
+	for i, call := range calls {
+		for j, name := range created {
+			if i != j && name != "" && created[i] != name && mentions(call.Arguments, name) {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	ordered := make([]orderedToolCall, 0, len(calls))
+	done := make([]bool, len(calls))
+	for len(ordered) < len(calls) {
+		next := -1
+		for i := range calls {
+			if !done[i] && allDone(deps[i], done) {
+				next = i
+				break
+			}
+		}
+		if next < 0 { // cycle: fall back to the model's order
+			for i := range calls {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+
+		done[next] = true
+		entry := orderedToolCall{Call: calls[next]}
+		for _, dep := range deps[next] {
+			if calls[dep].ID != "" {
+				entry.DependsOn = append(entry.DependsOn, calls[dep].ID)
+			}
+		}
+		ordered = append(ordered, entry)
+	}
+	return ordered
+}
+
+func createdName(args map[string]interface{}) string {
+	for _, key := range createdNameArgs {
+		if name, ok := args[key].(string); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// mentions reports whether any string in v, searched recursively, equals name
+func mentions(v interface{}, name string) bool {
+	switch value := v.(type) {
+	case string:
+		return value == name
+	case map[string]interface{}:
+		for _, item := range value {
+			if mentions(item, name) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if mentions(item, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allDone(indices []int, done []bool) bool {
+	for _, i := range indices {
+		if !done[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+)
+
+// DefaultToolRefreshInterval is how often the tool list is re-read from the MCP server
+const DefaultToolRefreshInterval = 5 * time.Minute
+
+// currentTools returns the tool list the AI is offered
+func (s *OrchestrationService) currentTools() []*mcp.Tool {
+	s.toolsMu.RLock()
+	defer s.toolsMu.RUnlock()
+	return s.tools
+}
+
+// RefreshTools re-lists the MCP server's tools and swaps in the new list, applying the
+// tool filter. It reports whether the set of tool names changed
+func (s *OrchestrationService) RefreshTools(ctx context.Context) (bool, error) {
+	tools, err := s.mcpClient.ListTools(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to refresh tools: %w", err)
+	}
+
+	s.toolsMu.Lock()
+	tools = s.toolFilter.Filter(tools)
+	added, removed := diffToolNames(s.tools, tools)
+	s.tools = tools
+	s.toolsMu.Unlock()
+
+	// The blueprints tool may have changed with the rest; re-read the catalog on next use
+	s.blueprintCache.Invalidate()
+
+	changed := len(added) > 0 || len(removed) > 0
+	if changed {
+		log.Printf("MCP tool set changed: added %v, removed %v (%d tools)", added, removed, len(tools))
+	}
+	return changed, nil
+}
+
+// StartToolRefresher refreshes the tool list every interval until the service is closed.
+// An interval of zero or less disables periodic refreshes
+func (s *OrchestrationService) StartToolRefresher(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.RefreshTools(context.Background()); err != nil {
+					log.Printf("Periodic tool refresh failed: %v", err)
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// diffToolNames returns the sorted names of tools only in next (added) and only in prev (removed)
+func diffToolNames(prev, next []*mcp.Tool) (added, removed []string) {
+	prevNames := make(map[string]bool, len(prev))
+	for _, tool := range prev {
+		prevNames[tool.Name] = true
+	}
+	nextNames := make(map[string]bool, len(next))
+	for _, tool := range next {
+		nextNames[tool.Name] = true
+		if !prevNames[tool.Name] {
+			added = append(added, tool.Name)
+		}
+	}
+	for _, tool := range prev {
+		if !nextNames[tool.Name] {
+			removed = append(removed, tool.Name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
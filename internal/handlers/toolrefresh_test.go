@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// availableToolNames returns the sorted names GetAvailableTools reports
+func availableToolNames(s *OrchestrationService) []string {
+	var names []string
+	for _, info := range s.GetAvailableTools() {
+		names = append(names, info.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRefreshToolsPicksUpServerChanges(t *testing.T) {
+	server := newTestMCPServer(t, testTool{name: "get_blueprints"}, testTool{name: "create_resource"})
+	service := newTestService(t, server, newFakeProvider("fake"), 5, time.Minute)
+
+	changed, err := service.RefreshTools(context.Background())
+	if err != nil || changed {
+		t.Fatalf("RefreshTools without server changes = %v, %v; want false, nil", changed, err)
+	}
+
+	server.addTool(testTool{name: "scale_deployment"})
+	server.server.RemoveTools("create_resource")
+
+	changed, err = service.RefreshTools(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("RefreshTools after server changes = %v, %v; want true, nil", changed, err)
+	}
+	if got, want := availableToolNames(service), []string{"get_blueprints", "scale_deployment"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAvailableTools() = %v, want %v", got, want)
+	}
+}
+
+func TestToolsRefreshHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := newTestMCPServer(t, testTool{name: "get_blueprints"})
+	service := newTestService(t, server, newFakeProvider("fake"), 5, time.Minute)
+	router := gin.New()
+	SetupRoutes(router, NewHandler(service, nil))
+
+	server.addTool(testTool{name: "list_deployments"})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/tools/refresh", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var resp models.ToolsRefreshResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Changed || len(resp.Tools) != 2 {
+		t.Errorf("response = %+v, want changed with 2 tools", resp)
+	}
+}
+
+func TestDiffToolNames(t *testing.T) {
+	tools := func(names ...string) []*mcp.Tool {
+		var list []*mcp.Tool
+		for _, name := range names {
+			list = append(list, &mcp.Tool{Name: name})
+		}
+		return list
+	}
+	added, removed := diffToolNames(tools("a", "b", "c"), tools("c", "d", "a"))
+	if !reflect.DeepEqual(added, []string{"d"}) || !reflect.DeepEqual(removed, []string{"b"}) {
+		t.Errorf("diffToolNames = added %v, removed %v; want [d], [b]", added, removed)
+	}
+	if added, removed := diffToolNames(tools("a"), tools("a")); added != nil || removed != nil {
+		t.Errorf("unchanged tools: added %v, removed %v", added, removed)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TraceIDHeader carries the request's trace ID in both directions
+const TraceIDHeader = "X-Trace-ID"
+
+type traceIDKey struct{}
+
+// validTraceID limits caller-supplied IDs to something safe to echo and log
+var validTraceID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// TraceMiddleware assigns every request a trace ID, reusing one supplied in X-Trace-ID or
+// a W3C traceparent header, and returns it in the X-Trace-ID response header
+func TraceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID := incomingTraceID(c)
+		if traceID == "" {
+			traceID = newTraceID()
+		}
+
+		c.Set(TraceIDHeader, traceID)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), traceIDKey{}, traceID))
+		c.Header(TraceIDHeader, traceID)
+		c.Next()
+	}
+}
+
+// TraceIDFromContext returns the trace ID assigned by TraceMiddleware, or "" if there is none
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+func incomingTraceID(c *gin.Context) string {
+	if id := c.GetHeader(TraceIDHeader); validTraceID.MatchString(id) {
+		return id
+	}
+	// traceparent: version-traceid-parentid-flags
+	if parts := strings.Split(c.GetHeader("traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		if _, err := hex.DecodeString(parts[1]); err == nil {
+			return strings.ToLower(parts[1])
+		}
+	}
+	return ""
+}
+
+func newTraceID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// traceID returns the trace ID for a gin request
+func traceID(c *gin.Context) string {
+	return c.GetString(TraceIDHeader)
+}
@@ -0,0 +1,26 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Supported LOG_FORMAT values
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// NewLogger returns a logger writing to stderr in the given format: "text" for
+// human-readable key=value lines or "json" for one JSON object per line
+func NewLogger(format string) (*slog.Logger, error) {
+	switch format {
+	case FormatText, "":
+		return slog.New(slog.NewTextHandler(os.Stderr, nil)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil)), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q (use text or json)", format)
+	}
+}
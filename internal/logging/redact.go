@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactPatterns are the field-name fragments masked when no patterns are configured
+var DefaultRedactPatterns = []string{"password", "token", "secret", "key"}
+
+var (
+	mu             sync.RWMutex
+	redactPatterns = DefaultRedactPatterns
+	textPattern    = buildTextPattern(DefaultRedactPatterns)
+)
+
+// SetRedactPatterns replaces the field-name fragments whose values are masked in logs
+func SetRedactPatterns(patterns []string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	redactPatterns = patterns
+	textPattern = buildTextPattern(patterns)
+}
+
+// buildTextPattern matches "name: value" / "name=value" pairs whose name contains a pattern
+func buildTextPattern(patterns []string) *regexp.Regexp {
+	if len(patterns) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(patterns))
+	for i, p := range patterns {
+		quoted[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile(`(?i)([\w-]*(?:` + strings.Join(quoted, "|") + `)[\w-]*"?\s*[:=]\s*"?)([^\s",}]+)`)
+}
+
+// isSensitive reports whether a field name matches any redact pattern
+func isSensitive(field string) bool {
+	field = strings.ToLower(field)
+	for _, p := range redactPatterns {
+		if strings.Contains(field, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of v with the values of sensitive map keys masked.
+// Nested maps and slices are walked; other values are returned unchanged.
+func Redact(v interface{}) interface{} {
+	mu.RLock()
+	defer mu.RUnlock()
+	return redact(v)
+}
+
+func redact(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if isSensitive(k) {
+				out[k] = redactedValue
+			} else {
+				out[k] = redact(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = redact(item)
+		}
+		return out
+	case string:
+		return redactText(val)
+	default:
+		return v
+	}
+}
+
+// RedactString masks "name: value" and "name=value" pairs in free text whose name is sensitive
+func RedactString(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return redactText(s)
+}
+
+func redactText(s string) string {
+	if textPattern == nil {
+		return s
+	}
+	return textPattern.ReplaceAllString(s, "${1}"+redactedValue)
+}
+
+// Sprint formats v with sensitive values masked, for use in log statements
+func Sprint(v interface{}) string {
+	return fmt.Sprintf("%v", Redact(v))
+}
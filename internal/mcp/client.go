@@ -2,13 +2,37 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// ErrNotConnected is returned when the client has no live session, because
+// initialization failed or the client was closed
+var ErrNotConnected = errors.New("MCP client is not initialized or has been closed")
+
+// TimeoutError is returned when an MCP request or the initialize handshake exceeds its
+// configured timeout. It matches context.DeadlineExceeded with errors.Is
+type TimeoutError struct {
+	Op      string // "initialize", "call tool <name>", "list tools", ...
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("MCP %s timed out after %s", e.Op, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
 // Client wraps the official MCP SDK client
 type Client struct {
 	mcpClient   *mcp.Client
@@ -18,33 +42,88 @@ type Client struct {
 	tools       []*mcp.Tool
 	mu          sync.RWMutex
 	initialized bool
+	closed      bool
+
+	callTimeout    time.Duration // per-request limit for tool, resource and prompt calls (0 = none)
+	connectTimeout time.Duration // limit for the initialize handshake (0 = none)
+}
+
+// ClientOptions tunes what the client advertises in the initialize handshake. The protocol
+// version isn't configurable: the SDK requests the latest version it supports and accepts any
+// supported version the server answers with
+type ClientOptions struct {
+	Name         string        // clientInfo.name; defaults to "idp-cloudgenie-backend"
+	Version      string        // clientInfo.version; defaults to "1.0.0"
+	Capabilities []string      // Optional capabilities to advertise: "sampling", "elicitation"
+	KeepAlive    time.Duration // Ping interval; the session is closed if pings fail (0 = no pings)
+
+	CallTimeout    time.Duration // Limit for each tool, resource or prompt request (0 = no limit)
+	ConnectTimeout time.Duration // Limit for the initialize handshake (0 = no limit)
 }
 
+// errCapabilityUnsupported answers server requests for capabilities that are only
+// advertised for compatibility with servers that require them
+var errCapabilityUnsupported = errors.New("capability is advertised for compatibility but not supported by this client")
+
 // NewClient creates a new MCP client using the official SDK with HTTP transport
-func NewClient(mcpServerURL string, env []string) (*Client, error) {
+func NewClient(mcpServerURL string, env []string, opts ClientOptions) (*Client, error) {
 	// Create the official MCP client
 	impl := &mcp.Implementation{
 		Name:    "idp-cloudgenie-backend",
 		Version: "1.0.0",
 	}
+	if opts.Name != "" {
+		impl.Name = opts.Name
+	}
+	if opts.Version != "" {
+		impl.Version = opts.Version
+	}
+
+	// The SDK advertises sampling and elicitation when their handlers are set
+	clientOpts := &mcp.ClientOptions{KeepAlive: opts.KeepAlive}
+	for _, capability := range opts.Capabilities {
+		switch strings.ToLower(capability) {
+		case "sampling":
+			clientOpts.CreateMessageHandler = func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+				return nil, fmt.Errorf("sampling: %w", errCapabilityUnsupported)
+			}
+		case "elicitation":
+			clientOpts.ElicitationHandler = func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+				return nil, fmt.Errorf("elicitation: %w", errCapabilityUnsupported)
+			}
+		default:
+			return nil, fmt.Errorf("unknown MCP client capability %q (supported: sampling, elicitation)", capability)
+		}
+	}
 
-	mcpClient := mcp.NewClient(impl, nil)
+	mcpClient := mcp.NewClient(impl, clientOpts)
 
 	client := &Client{
 		mcpClient:  mcpClient,
 		serverURL:  mcpServerURL,
 		httpClient: &http.Client{},
 		tools:      []*mcp.Tool{},
+
+		callTimeout:    opts.CallTimeout,
+		connectTimeout: opts.ConnectTimeout,
 	}
 
 	return client, nil
 }
 
-// Initialize performs the MCP initialization handshake over HTTP
-func (c *Client) Initialize() error {
+// Initialize performs the MCP initialization handshake over HTTP.
+// ctx bounds the handshake only; the session outlives it
+func (c *Client) Initialize(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return c.initializeLocked(ctx)
+}
 
+// initializeLocked connects if the client isn't initialized yet; c.mu must be held
+func (c *Client) initializeLocked(ctx context.Context) error {
+	if c.closed {
+		return ErrNotConnected
+	}
 	if c.initialized {
 		return nil
 	}
@@ -56,30 +135,219 @@ func (c *Client) Initialize() error {
 	}
 
 	// Connect to the MCP server over HTTP
-	ctx := context.Background()
-	session, err := c.mcpClient.Connect(ctx, transport, nil)
+	session, err := c.connect(ctx, transport)
 	if err != nil {
 		return fmt.Errorf("failed to connect to MCP server via HTTP: %w", err)
 	}
 
 	c.session = session
 	c.initialized = true
+	logInitializeResult(session.InitializeResult())
 
 	return nil
 }
 
-// ListTools retrieves the list of available tools from the MCP server
-func (c *Client) ListTools() ([]*mcp.Tool, error) {
-	if !c.initialized {
-		if err := c.Initialize(); err != nil {
+type connectResult struct {
+	session *mcp.ClientSession
+	err     error
+}
+
+// connect runs the initialize handshake, giving up when ctx ends or the connect timeout
+// passes. The transport ties the connection's lifetime to the context it connects with, so
+// the handshake runs on an uncancellable context and is abandoned rather than cancelled;
+// a session that completes after being abandoned is closed
+func (c *Client) connect(ctx context.Context, transport mcp.Transport) (*mcp.ClientSession, error) {
+	done := make(chan connectResult, 1)
+	go func() {
+		session, err := c.mcpClient.Connect(context.WithoutCancel(ctx), transport, nil)
+		done <- connectResult{session, err}
+	}()
+
+	var timeout <-chan time.Time
+	if c.connectTimeout > 0 {
+		timer := time.NewTimer(c.connectTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case result := <-done:
+		return result.session, result.err
+	case <-timeout:
+		go closeAbandoned(done)
+		return nil, &TimeoutError{Op: "initialize", Timeout: c.connectTimeout}
+	case <-ctx.Done():
+		go closeAbandoned(done)
+		return nil, ctx.Err()
+	}
+}
+
+func closeAbandoned(done <-chan connectResult) {
+	if result := <-done; result.session != nil {
+		result.session.Close()
+	}
+}
+
+// withCallTimeout bounds one MCP request by the configured call timeout
+func (c *Client) withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.callTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.callTimeout)
+}
+
+// callError returns a TimeoutError when a request failed because the call timeout expired,
+// rather than because the caller's own context ended, and wraps err otherwise
+func (c *Client) callError(ctx, callCtx context.Context, op string, err error) error {
+	if ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Op: op, Timeout: c.callTimeout}
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
+
+// logInitializeResult logs the negotiated protocol version and server capabilities,
+// for troubleshooting compatibility with a server
+func logInitializeResult(result *mcp.InitializeResult) {
+	if result == nil {
+		return
+	}
+
+	server := "unknown"
+	if result.ServerInfo != nil {
+		server = result.ServerInfo.Name + " " + result.ServerInfo.Version
+	}
+
+	var capabilities []string
+	if caps := result.Capabilities; caps != nil {
+		if caps.Tools != nil {
+			capabilities = append(capabilities, "tools")
+		}
+		if caps.Resources != nil {
+			capabilities = append(capabilities, "resources")
+		}
+		if caps.Prompts != nil {
+			capabilities = append(capabilities, "prompts")
+		}
+		if caps.Logging != nil {
+			capabilities = append(capabilities, "logging")
+		}
+		if caps.Completions != nil {
+			capabilities = append(capabilities, "completions")
+		}
+		for name := range caps.Experimental {
+			capabilities = append(capabilities, "experimental:"+name)
+		}
+	}
+
+	log.Printf("MCP session initialized: server %s, protocol %s, server capabilities [%s]",
+		server, result.ProtocolVersion, strings.Join(capabilities, ", "))
+}
+
+// reconnect replaces a session that stopped working, e.g. because the MCP server restarted.
+// Concurrent callers that saw the same stale session reconnect only once: later ones find
+// it already replaced and reuse the new session
+func (c *Client) reconnect(ctx context.Context, stale *mcp.ClientSession) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrNotConnected
+	}
+	if c.session != stale && c.session != nil {
+		return nil
+	}
+
+	if stale != nil {
+		_ = stale.Close()
+	}
+	c.session = nil
+	c.initialized = false
+	return c.initializeLocked(ctx)
+}
+
+// isConnectionError reports whether err means the session itself is gone (closed stream,
+// dropped connection, or a server that no longer knows the session) rather than a failed
+// request or a timeout
+func isConnectionError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, mcp.ErrConnectionClosed) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && !netErr.Timeout() {
+		return true
+	}
+	// The SDK's session-missing error (server returned 404 for our session ID) is unexported
+	return strings.Contains(err.Error(), "session not found")
+}
+
+// withSession runs call on the live session. If the session turns out to be lost, the client
+// reconnects and runs call once more. A tool call interrupted by a dropped connection may
+// therefore reach the server twice
+func (c *Client) withSession(ctx context.Context, call func(*mcp.ClientSession) error) error {
+	session, err := c.getSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = call(session)
+	if err == nil || ctx.Err() != nil || !isConnectionError(err) {
+		return err
+	}
+
+	log.Printf("MCP session lost (%v), reconnecting", err)
+	if reconnectErr := c.reconnect(ctx, session); reconnectErr != nil {
+		return fmt.Errorf("%w (reconnect failed: %v)", err, reconnectErr)
+	}
+	session, err = c.getSession(ctx)
+	if err != nil {
+		return err
+	}
+	return call(session)
+}
+
+// getSession returns the live session, initializing the client on first use
+func (c *Client) getSession(ctx context.Context) (*mcp.ClientSession, error) {
+	c.mu.RLock()
+	initialized, closed, session := c.initialized, c.closed, c.session
+	c.mu.RUnlock()
+
+	if closed {
+		return nil, ErrNotConnected
+	}
+	if !initialized {
+		if err := c.Initialize(ctx); err != nil {
 			return nil, err
 		}
+		c.mu.RLock()
+		session = c.session
+		c.mu.RUnlock()
 	}
 
-	ctx := context.Background()
-	result, err := c.session.ListTools(ctx, &mcp.ListToolsParams{})
+	if session == nil {
+		return nil, ErrNotConnected
+	}
+	return session, nil
+}
+
+// ListTools retrieves the list of available tools from the MCP server
+func (c *Client) ListTools(ctx context.Context) ([]*mcp.Tool, error) {
+	var result *mcp.ListToolsResult
+	err := c.withSession(ctx, func(session *mcp.ClientSession) error {
+		callCtx, cancel := c.withCallTimeout(ctx)
+		defer cancel()
+		var err error
+		result, err = session.ListTools(callCtx, &mcp.ListToolsParams{})
+		if err != nil {
+			return c.callError(ctx, callCtx, "list tools", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tools: %w", err)
+		return nil, err
 	}
 
 	c.mu.Lock()
@@ -90,22 +358,110 @@ func (c *Client) ListTools() ([]*mcp.Tool, error) {
 }
 
 // CallTool executes a tool on the MCP server
-func (c *Client) CallTool(name string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	if !c.initialized {
-		if err := c.Initialize(); err != nil {
-			return nil, err
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	params := &mcp.CallToolParams{
+		Name:      name,
+		Arguments: arguments,
+	}
+
+	var result *mcp.CallToolResult
+	err := c.withSession(ctx, func(session *mcp.ClientSession) error {
+		callCtx, cancel := c.withCallTimeout(ctx)
+		defer cancel()
+		var err error
+		result, err = session.CallTool(callCtx, params)
+		if err != nil {
+			return c.callError(ctx, callCtx, "call tool "+name, err)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	ctx := context.Background()
-	params := &mcp.CallToolParams{
+	return result, nil
+}
+
+// ListResources retrieves the list of readable resources from the MCP server
+func (c *Client) ListResources(ctx context.Context) ([]*mcp.Resource, error) {
+	var result *mcp.ListResourcesResult
+	err := c.withSession(ctx, func(session *mcp.ClientSession) error {
+		callCtx, cancel := c.withCallTimeout(ctx)
+		defer cancel()
+		var err error
+		result, err = session.ListResources(callCtx, &mcp.ListResourcesParams{})
+		if err != nil {
+			return c.callError(ctx, callCtx, "list resources", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Resources, nil
+}
+
+// ReadResource reads the contents of a resource from the MCP server
+func (c *Client) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	var result *mcp.ReadResourceResult
+	err := c.withSession(ctx, func(session *mcp.ClientSession) error {
+		callCtx, cancel := c.withCallTimeout(ctx)
+		defer cancel()
+		var err error
+		result, err = session.ReadResource(callCtx, &mcp.ReadResourceParams{URI: uri})
+		if err != nil {
+			return c.callError(ctx, callCtx, "read resource "+uri, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListPrompts retrieves the list of prompt templates from the MCP server
+func (c *Client) ListPrompts(ctx context.Context) ([]*mcp.Prompt, error) {
+	var result *mcp.ListPromptsResult
+	err := c.withSession(ctx, func(session *mcp.ClientSession) error {
+		callCtx, cancel := c.withCallTimeout(ctx)
+		defer cancel()
+		var err error
+		result, err = session.ListPrompts(callCtx, &mcp.ListPromptsParams{})
+		if err != nil {
+			return c.callError(ctx, callCtx, "list prompts", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Prompts, nil
+}
+
+// GetPrompt renders a prompt template on the MCP server with the given arguments
+func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*mcp.GetPromptResult, error) {
+	params := &mcp.GetPromptParams{
 		Name:      name,
 		Arguments: arguments,
 	}
 
-	result, err := c.session.CallTool(ctx, params)
+	var result *mcp.GetPromptResult
+	err := c.withSession(ctx, func(session *mcp.ClientSession) error {
+		callCtx, cancel := c.withCallTimeout(ctx)
+		defer cancel()
+		var err error
+		result, err = session.GetPrompt(callCtx, params)
+		if err != nil {
+			return c.callError(ctx, callCtx, "get prompt "+name, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to call tool %s: %w", name, err)
+		return nil, err
 	}
 
 	return result, nil
@@ -123,8 +479,13 @@ func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.session != nil {
-		return c.session.Close()
+	// Mark closed so later calls get ErrNotConnected instead of using a dead session
+	c.closed = true
+	if c.session == nil {
+		return nil
 	}
-	return nil
-}
\ No newline at end of file
+
+	session := c.session
+	c.session = nil
+	return session.Close()
+}
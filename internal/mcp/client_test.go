@@ -0,0 +1,234 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// newSlowServer serves a "fast" tool and a "slow" tool that doesn't answer until its
+// request is cancelled or the test ends
+func newSlowServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := sdkmcp.NewServer(&sdkmcp.Implementation{Name: "slow-server", Version: "1.0.0"}, nil)
+	schema := map[string]any{"type": "object"}
+	server.AddTool(&sdkmcp.Tool{Name: "fast", InputSchema: schema},
+		func(ctx context.Context, req *sdkmcp.CallToolRequest) (*sdkmcp.CallToolResult, error) {
+			return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: "ok"}}}, nil
+		})
+	server.AddTool(&sdkmcp.Tool{Name: "slow", InputSchema: schema},
+		func(ctx context.Context, req *sdkmcp.CallToolRequest) (*sdkmcp.CallToolResult, error) {
+			select {
+			case <-ctx.Done():
+			case <-release:
+			}
+			return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: "late"}}}, nil
+		})
+
+	httpServer := httptest.NewServer(sdkmcp.NewStreamableHTTPHandler(func(*http.Request) *sdkmcp.Server { return server }, nil))
+	t.Cleanup(func() {
+		close(release)
+		httpServer.Close()
+	})
+	return httpServer
+}
+
+func newTestClient(t *testing.T, url string, opts ClientOptions) *Client {
+	t.Helper()
+	client, err := NewClient(url, nil, opts)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCallToolStopsWhenContextCancelled(t *testing.T) {
+	client := newTestClient(t, newSlowServer(t).URL, ClientOptions{})
+	if err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.CallTool(ctx, "slow", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CallTool error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("CallTool returned %s after the cancel", elapsed)
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		t.Error("a cancelled call was reported as a timeout")
+	}
+}
+
+func TestCallToolTimeout(t *testing.T) {
+	client := newTestClient(t, newSlowServer(t).URL, ClientOptions{CallTimeout: 100 * time.Millisecond})
+
+	if _, err := client.CallTool(context.Background(), "fast", nil); err != nil {
+		t.Fatalf("fast tool: %v", err)
+	}
+
+	_, err := client.CallTool(context.Background(), "slow", nil)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("slow tool error = %v, want *TimeoutError", err)
+	}
+	if timeoutErr.Op != "call tool slow" || timeoutErr.Timeout != 100*time.Millisecond {
+		t.Errorf("TimeoutError = %+v", timeoutErr)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("TimeoutError doesn't match context.DeadlineExceeded")
+	}
+}
+
+func TestInitializeConnectTimeout(t *testing.T) {
+	// The server accepts connections but doesn't answer the handshake until the test ends.
+	// The abandoned handshake never disconnects, so the handler must be released before Close
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(hung.Close)
+	t.Cleanup(func() { close(release) })
+	client := newTestClient(t, hung.URL, ClientOptions{ConnectTimeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	err := client.Initialize(context.Background())
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Op != "initialize" {
+		t.Fatalf("Initialize error = %v, want an initialize *TimeoutError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Initialize returned after %s, want about 100ms", elapsed)
+	}
+}
+
+// restartableServer is an MCP endpoint whose server can be restarted, dropping every session
+type restartableServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	handler http.Handler
+}
+
+func newRestartableServer(t *testing.T) *restartableServer {
+	t.Helper()
+	s := &restartableServer{}
+	s.restart()
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		handler := s.handler
+		s.mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// restart replaces the server with a fresh one that knows none of the earlier sessions
+func (s *restartableServer) restart() {
+	server := sdkmcp.NewServer(&sdkmcp.Implementation{Name: "restartable-server", Version: "1.0.0"}, nil)
+	server.AddTool(&sdkmcp.Tool{Name: "echo", InputSchema: map[string]any{"type": "object"}},
+		func(ctx context.Context, req *sdkmcp.CallToolRequest) (*sdkmcp.CallToolResult, error) {
+			return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: "ok"}}}, nil
+		})
+	server.AddPrompt(&sdkmcp.Prompt{Name: "greet"},
+		func(ctx context.Context, req *sdkmcp.GetPromptRequest) (*sdkmcp.GetPromptResult, error) {
+			return &sdkmcp.GetPromptResult{Messages: []*sdkmcp.PromptMessage{{Role: "user", Content: &sdkmcp.TextContent{Text: "hello"}}}}, nil
+		})
+	server.AddResource(&sdkmcp.Resource{Name: "readme", URI: "file:///readme.md"},
+		func(ctx context.Context, req *sdkmcp.ReadResourceRequest) (*sdkmcp.ReadResourceResult, error) {
+			return &sdkmcp.ReadResourceResult{Contents: []*sdkmcp.ResourceContents{{URI: req.Params.URI, Text: "docs"}}}, nil
+		})
+	s.mu.Lock()
+	s.handler = sdkmcp.NewStreamableHTTPHandler(func(*http.Request) *sdkmcp.Server { return server }, nil)
+	s.mu.Unlock()
+}
+
+func TestClientReconnectsAfterServerRestart(t *testing.T) {
+	server := newRestartableServer(t)
+	client := newTestClient(t, server.URL, ClientOptions{})
+	ctx := context.Background()
+	if _, err := client.CallTool(ctx, "echo", nil); err != nil {
+		t.Fatalf("CallTool before restart: %v", err)
+	}
+	client.mu.RLock()
+	before := client.session
+	client.mu.RUnlock()
+
+	server.restart()
+
+	if _, err := client.CallTool(ctx, "echo", nil); err != nil {
+		t.Fatalf("CallTool after restart: %v", err)
+	}
+	client.mu.RLock()
+	after := client.session
+	client.mu.RUnlock()
+	if after == before {
+		t.Error("CallTool succeeded without reconnecting")
+	}
+
+	server.restart()
+	if _, err := client.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools after restart: %v", err)
+	}
+
+	// Resource and prompt requests recover the same way
+	server.restart()
+	if _, err := client.ReadResource(ctx, "file:///readme.md"); err != nil {
+		t.Fatalf("ReadResource after restart: %v", err)
+	}
+	server.restart()
+	if _, err := client.ListPrompts(ctx); err != nil {
+		t.Fatalf("ListPrompts after restart: %v", err)
+	}
+	server.restart()
+	if _, err := client.GetPrompt(ctx, "greet", nil); err != nil {
+		t.Fatalf("GetPrompt after restart: %v", err)
+	}
+	server.restart()
+	if _, err := client.ListResources(ctx); err != nil {
+		t.Fatalf("ListResources after restart: %v", err)
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"closed connection", sdkmcp.ErrConnectionClosed, true},
+		{"unexpected EOF", fmt.Errorf("reading response: %w", io.ErrUnexpectedEOF), true},
+		{"connection refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"unknown session", errors.New("calling \"tools/call\": session not found"), true},
+		{"timeout", &TimeoutError{Op: "call tool x", Timeout: time.Second}, false},
+		{"cancelled", fmt.Errorf("call: %w", context.Canceled), false},
+		{"tool error", errors.New("invalid params: missing name"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -20,6 +20,30 @@ type (
 
 	// TextContent represents text content (alias for SDK type)
 	TextContent = mcp.TextContent
+
+	// ImageContent represents image content (alias for SDK type)
+	ImageContent = mcp.ImageContent
+
+	// AudioContent represents audio content (alias for SDK type)
+	AudioContent = mcp.AudioContent
+
+	// EmbeddedResource represents an embedded resource (alias for SDK type)
+	EmbeddedResource = mcp.EmbeddedResource
+
+	// ResourceLink represents a link to a resource (alias for SDK type)
+	ResourceLink = mcp.ResourceLink
+
+	// Resource represents a readable MCP resource (alias for SDK type)
+	Resource = mcp.Resource
+
+	// ReadResourceResult represents the contents of a read resource (alias for SDK type)
+	ReadResourceResult = mcp.ReadResourceResult
+
+	// Prompt represents an MCP prompt template (alias for SDK type)
+	Prompt = mcp.Prompt
+
+	// GetPromptResult represents a rendered prompt (alias for SDK type)
+	GetPromptResult = mcp.GetPromptResult
 )
 
 // ToolContent is a helper to extract text from Content interface
@@ -38,4 +62,3 @@ func FormatToolResult(result *mcp.CallToolResult) string {
 	}
 	return text
 }
-
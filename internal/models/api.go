@@ -1,11 +1,31 @@
 package models
 
+import "time"
+
 // Request and Response types for the API
 type ChatRequest struct {
 	Prompt   string                 `json:"prompt" binding:"required"`
-	Provider string                 `json:"provider,omitempty"` // "openai" or "anthropic", defaults to openai
-	Model    string                 `json:"model,omitempty"`
+	Provider string                 `json:"provider,omitempty"` // "openai", "anthropic", "gemini", "glean", "bedrock", "ollama", "cohere" or "mistral"; defaults to the configured provider
+	Model    string                 `json:"model,omitempty"`    // Overrides the provider's configured model
 	Context  map[string]interface{} `json:"context,omitempty"`
+	// ConfirmationToken confirms a destructive tool call held in a previous response
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+	// IncludeIntermediate returns the assistant's narration from tool-calling iterations
+	IncludeIntermediate bool `json:"include_intermediate,omitempty"`
+	// MaxIterations overrides the configured tool-iteration limit for this request (capped server-side)
+	MaxIterations int `json:"max_iterations,omitempty" binding:"omitempty,min=1"`
+	// GenerationConfig overrides the provider's sampling parameters for this request
+	GenerationConfig *GenerationConfig `json:"generation_config,omitempty"`
+	// SessionID continues a stored conversation; requests without one are stateless
+	SessionID string `json:"session_id,omitempty" binding:"omitempty,max=128"`
+}
+
+// GenerationConfig holds optional sampling parameters; unset fields keep the provider defaults
+type GenerationConfig struct {
+	Temperature *float32 `json:"temperature,omitempty" binding:"omitempty,min=0,max=2"`
+	TopP        *float32 `json:"top_p,omitempty" binding:"omitempty,min=0,max=1"`
+	TopK        *int32   `json:"top_k,omitempty" binding:"omitempty,min=1"` // Ignored by OpenAI
+	MaxTokens   *int32   `json:"max_tokens,omitempty" binding:"omitempty,min=1"`
 }
 
 type ChatResponse struct {
@@ -13,12 +33,33 @@ type ChatResponse struct {
 	ToolCalls   []ToolCall             `json:"tool_calls,omitempty"`
 	ToolResults []ToolResult           `json:"tool_results,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	// PendingConfirmations lists destructive tool calls awaiting user confirmation
+	PendingConfirmations []PendingConfirmation `json:"pending_confirmations,omitempty"`
+	// IntermediateResponses holds the assistant's narration from each tool-calling iteration, in order
+	IntermediateResponses []string `json:"intermediate_responses,omitempty"`
+	// TraceID identifies this request in the server logs
+	TraceID string `json:"trace_id,omitempty"`
+	// SessionID echoes the request's session, whose history now includes this turn
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// PendingConfirmation describes a destructive tool call that only runs once
+// its token is sent back in ChatRequest.ConfirmationToken
+type PendingConfirmation struct {
+	Token     string                 `json:"token"`
+	ToolName  string                 `json:"tool_name"`
+	Arguments map[string]interface{} `json:"arguments"`
+	ExpiresAt time.Time              `json:"expires_at"`
 }
 
 type ToolCall struct {
 	ID        string                 `json:"id"`
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments"`
+	Iteration int                    `json:"iteration"` // Loop pass that made the call; 0 for a confirmed call run before the loop
+	Sequence  int                    `json:"sequence"`  // 1-based position in the order the calls actually ran
+	// DependsOn lists calls from the same batch that had to run first because this call names what they create
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 type ToolResult struct {
@@ -26,17 +67,28 @@ type ToolResult struct {
 	Name       string `json:"name"`
 	Content    string `json:"content"`
 	IsError    bool   `json:"is_error,omitempty"`
+	Iteration  int    `json:"iteration"` // Loop pass that produced the result; 0 for a confirmed call
+	// Classification of failed calls, so clients can e.g. offer a retry for transient failures
+	ErrorType string `json:"error_type,omitempty"` // "connection", "timeout", "validation", "not_found", "transient" or "tool_error"
+	ErrorCode string `json:"error_code,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
+	// EstimatedProvisioningSeconds is set on successful creates whose blueprint has an estimate
+	EstimatedProvisioningSeconds int `json:"estimated_provisioning_seconds,omitempty"`
+	// DeprecationWarning is set on creates against a deprecated blueprint
+	DeprecationWarning string `json:"deprecation_warning,omitempty"`
 }
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-	Code    int    `json:"code,omitempty"`
+	Error   ErrorCode `json:"error"`
+	Message string    `json:"message"`
+	Code    int       `json:"code,omitempty"`
+	TraceID string    `json:"trace_id,omitempty"` // Identifies the request in the server logs
 }
 
 type HealthResponse struct {
 	Status         string            `json:"status"`
 	MCPServerReady bool              `json:"mcp_server_ready"`
+	Maintenance    bool              `json:"maintenance"` // Creating, updating and deleting resources is disabled
 	Services       map[string]string `json:"services"`
 }
 
@@ -44,8 +96,175 @@ type ToolsResponse struct {
 	Tools []ToolInfo `json:"tools"`
 }
 
+// ToolsRefreshResponse is the tool list after a forced refresh
+type ToolsRefreshResponse struct {
+	Changed bool       `json:"changed"` // Whether tools were added or removed
+	Tools   []ToolInfo `json:"tools"`
+}
+
 type ToolInfo struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
-	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Parameters  []ToolParameter        `json:"parameters"`
+	InputSchema map[string]interface{} `json:"input_schema,omitempty"` // Raw JSON Schema as published by the MCP server
+}
+
+// ToolParameter is a single tool argument normalized from the tool's JSON Schema
+type ToolParameter struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+}
+
+type MCPResourcesResponse struct {
+	Resources []MCPResourceInfo `json:"resources"`
+}
+
+type MCPResourceInfo struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mime_type,omitempty"`
+}
+
+type MCPResourceContentsResponse struct {
+	Contents []MCPResourceContent `json:"contents"`
+}
+
+type MCPResourceContent struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mime_type,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     []byte `json:"blob,omitempty"` // Base64-encoded in JSON
+}
+
+type MCPPromptsResponse struct {
+	Prompts []MCPPromptInfo `json:"prompts"`
+}
+
+type MCPPromptInfo struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Arguments   []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type MCPPromptRequest struct {
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type MCPPromptResponse struct {
+	Description string             `json:"description,omitempty"`
+	Messages    []MCPPromptMessage `json:"messages"`
+}
+
+type MCPPromptMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// BlueprintMatch is a blueprint that provides a requested service
+type BlueprintMatch struct {
+	Name      string `json:"name"`
+	MatchType string `json:"match_type"` // "exact", "substring" or "synonym"
+	// Deprecated blueprints still work but should not be used for new resources
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+}
+
+// BlueprintMatchResponse answers whether a requested service has a blueprint
+type BlueprintMatchResponse struct {
+	Query   string           `json:"query"`
+	Matched bool             `json:"matched"`
+	Matches []BlueprintMatch `json:"matches"`
+}
+
+// BlueprintRecommendRequest asks which blueprints fit a natural-language description
+type BlueprintRecommendRequest struct {
+	Description string `json:"description" binding:"required,max=2000"`
+	Limit       int    `json:"limit,omitempty" binding:"omitempty,min=1,max=20"` // Defaults to 5
+}
+
+// BlueprintRecommendation is a blueprint suggested for a description
+type BlueprintRecommendation struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Score        float64  `json:"score"`         // 0-1; how well the blueprint covers the description's terms
+	MatchedTerms []string `json:"matched_terms"` // Description terms the blueprint matched
+	// Deprecated blueprints still work but should not be used for new resources
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+}
+
+// BlueprintRecommendResponse lists suggested blueprints, best first
+type BlueprintRecommendResponse struct {
+	Description     string                    `json:"description"`
+	Count           int                       `json:"count"`
+	Recommendations []BlueprintRecommendation `json:"recommendations"`
+}
+
+// BlueprintRequiredField is a parameter that must be given to create from a blueprint
+type BlueprintRequiredField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// BlueprintRequiredResponse lists a blueprint's required parameters
+type BlueprintRequiredResponse struct {
+	Blueprint string                   `json:"blueprint"`
+	Count     int                      `json:"count"`
+	Required  []BlueprintRequiredField `json:"required"`
+}
+
+// BlueprintExport is one blueprint in the exported catalog
+type BlueprintExport struct {
+	Name        string                 `json:"name"`
+	Version     string                 `json:"version,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`    // JSON Schema of the blueprint's parameters
+	Blueprint   map[string]interface{} `json:"blueprint"` // The blueprint as returned by the MCP server
+	// EstimatedProvisioningSeconds is how long a create from the blueprint usually takes, if known
+	EstimatedProvisioningSeconds int `json:"estimated_provisioning_seconds,omitempty"`
+	// Deprecated blueprints still work but should not be used for new resources
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+}
+
+// BlueprintExportResponse is the whole blueprint catalog
+type BlueprintExportResponse struct {
+	Count      int               `json:"count"`
+	Blueprints []BlueprintExport `json:"blueprints"`
+}
+
+// MaintenanceRequest turns maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// MaintenanceResponse is the current maintenance mode
+type MaintenanceResponse struct {
+	Enabled bool       `json:"enabled"`
+	Since   *time.Time `json:"since,omitempty"` // When maintenance was turned on; only set while enabled
+}
+
+// ConfigSetting is one effective configuration value; secrets are redacted
+type ConfigSetting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // "env", "file" (.env) or "default"
+}
+
+// AdminConfigResponse lists the configuration the running process loaded
+type AdminConfigResponse struct {
+	Settings []ConfigSetting `json:"settings"`
 }
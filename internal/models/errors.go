@@ -0,0 +1,46 @@
+package models
+
+import "net/http"
+
+// ErrorCode is a stable, machine-readable error identifier returned in ErrorResponse.Error
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest      ErrorCode = "invalid_request"      // Malformed body or missing parameter
+	ErrorCodeValidationFailed    ErrorCode = "validation_failed"    // Well-formed request with invalid values
+	ErrorCodeInvalidConfirmation ErrorCode = "invalid_confirmation" // Unknown, expired or reused confirmation token
+	ErrorCodeUnauthorized        ErrorCode = "unauthorized"         // Missing or invalid credentials
+	ErrorCodeForbidden           ErrorCode = "forbidden"            // Authenticated but not allowed
+	ErrorCodeNotFound            ErrorCode = "not_found"            // Requested item does not exist
+	ErrorCodeBlueprintNotFound   ErrorCode = "blueprint_not_found"  // Referenced blueprint does not exist
+	ErrorCodeTooManyRequests     ErrorCode = "too_many_requests"    // Server-side concurrency or rate limit hit
+	ErrorCodeQuotaExceeded       ErrorCode = "quota_exceeded"       // AI provider quota exhausted
+	ErrorCodeProcessingError     ErrorCode = "processing_error"     // Unexpected failure while processing
+	ErrorCodeAIError             ErrorCode = "ai_error"             // The AI provider returned an error
+	ErrorCodeAIUnavailable       ErrorCode = "ai_unavailable"       // The AI provider is down or overloaded; retry later
+	ErrorCodeMCPError            ErrorCode = "mcp_error"            // The MCP server returned an error
+	ErrorCodeProviderUnavailable ErrorCode = "provider_unavailable" // Requested AI provider isn't available
+	ErrorCodeMaintenance         ErrorCode = "maintenance"          // Mutating operations are disabled during maintenance
+)
+
+// HTTPStatus returns the HTTP status code an error code is served with
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case ErrorCodeInvalidRequest, ErrorCodeValidationFailed, ErrorCodeInvalidConfirmation, ErrorCodeProviderUnavailable:
+		return http.StatusBadRequest
+	case ErrorCodeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrorCodeForbidden:
+		return http.StatusForbidden
+	case ErrorCodeNotFound, ErrorCodeBlueprintNotFound:
+		return http.StatusNotFound
+	case ErrorCodeTooManyRequests, ErrorCodeQuotaExceeded:
+		return http.StatusTooManyRequests
+	case ErrorCodeAIError, ErrorCodeMCPError:
+		return http.StatusBadGateway
+	case ErrorCodeAIUnavailable, ErrorCodeMaintenance:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ConversationsCollection holds one document per chat session
+const ConversationsCollection = "conversations"
+
+// conversation is the stored form of a chat session
+type conversation struct {
+	SessionID string       `bson:"_id"`
+	Messages  []ai.Message `bson:"messages"`
+	CreatedAt time.Time    `bson:"created_at"`
+	UpdatedAt time.Time    `bson:"updated_at"`
+}
+
+// MongoRepository persists chat sessions in MongoDB
+type MongoRepository struct {
+	client        *mongo.Client
+	conversations *mongo.Collection
+}
+
+// NewMongoRepository connects to MongoDB at uri and uses the given database
+func NewMongoRepository(ctx context.Context, uri, database string) (*MongoRepository, error) {
+	// Decode nested documents (tool call arguments) as plain maps rather than bson.D
+	registry := bson.NewRegistry()
+	registry.RegisterTypeMapEntry(bsontype.EmbeddedDocument, reflect.TypeOf(bson.M{}))
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetRegistry(registry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	return &MongoRepository{
+		client:        client,
+		conversations: client.Database(database).Collection(ConversationsCollection),
+	}, nil
+}
+
+// GetConversation returns the messages stored for a session, or none for an unknown session
+func (r *MongoRepository) GetConversation(ctx context.Context, sessionID string) ([]ai.Message, error) {
+	var doc conversation
+	err := r.conversations.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation %s: %w", sessionID, err)
+	}
+	return doc.Messages, nil
+}
+
+// AppendConversation appends messages to a session, creating it if needed
+func (r *MongoRepository) AppendConversation(ctx context.Context, sessionID string, messages []ai.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$push":        bson.M{"messages": bson.M{"$each": messages}},
+		"$set":         bson.M{"updated_at": now},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	_, err := r.conversations.UpdateOne(ctx, bson.M{"_id": sessionID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save conversation %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// Close disconnects from MongoDB
+func (r *MongoRepository) Close(ctx context.Context) error {
+	return r.client.Disconnect(ctx)
+}
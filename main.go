@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/ai"
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/config"
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/handlers"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/logging"
 	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/mcp"
+	"github.com/deepakvbansode/idp-cloudgenie-backend/internal/store"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/cors"
 )
@@ -21,6 +27,14 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	logger, err := logging.NewLogger(cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	// Routes the standard log package through the logger too, so all output shares one format
+	slog.SetDefault(logger)
+	logging.SetRedactPatterns(cfg.LogRedactPatterns)
+	ai.SetToolPromptTokenBudget(cfg.ToolPromptTokenBudget)
 
 	log.Println("Starting CloudGenie Backend Service...")
 	log.Printf("AI Provider: %s", cfg.DefaultAIProvider)
@@ -32,64 +46,87 @@ func main() {
 	mcpEnv := []string{
 		fmt.Sprintf("CLOUDGENIE_BACKEND_URL=%s", cfg.CloudGenieBackendURL),
 	}
-	
-	mcpClient, err := mcp.NewClient(cfg.MCPServerURL, mcpEnv)
+
+	mcpClient, err := mcp.NewClient(cfg.MCPServerURL, mcpEnv, mcp.ClientOptions{
+		Name:         cfg.MCPClientName,
+		Version:      cfg.MCPClientVersion,
+		Capabilities: cfg.MCPClientCapabilities,
+		KeepAlive:    cfg.MCPKeepAlive,
+
+		CallTimeout:    cfg.MCPCallTimeout,
+		ConnectTimeout: cfg.MCPConnectTimeout,
+	})
 	if err != nil {
 		log.Fatalf("Failed to create MCP client: %v", err)
 	}
 	defer mcpClient.Close()
 
-	// Initialize AI Provider
-	log.Printf("Initializing AI provider: %s", cfg.DefaultAIProvider)
-	var aiProvider ai.Provider
-	
-	if cfg.DefaultAIProvider == "openai" || cfg.DefaultAIProvider == "" {
-		aiProvider, err = ai.NewOpenAIProvider(cfg.OpenAIAPIKey, cfg.OpenAIModel)
-	} else if cfg.DefaultAIProvider == "anthropic" {
-		aiProvider, err = ai.NewAnthropicProvider(cfg.AnthropicAPIKey, cfg.AnthropicModel)
-	} else if cfg.DefaultAIProvider == "gemini" {
-		aiProvider, err = ai.NewGeminiProvider(cfg.GeminiAPIKey, cfg.GeminiModel)
-	} else if cfg.DefaultAIProvider == "glean" {
-		aiProvider, err = ai.NewGleanProvider(cfg.GleanAPIKey, cfg.GleanInstance, cfg.GleanModel)
-	} else {
-		log.Fatalf("Unsupported AI provider: %s", cfg.DefaultAIProvider)
-	}
-
+	// Initialize AI providers: every provider with an API key can be selected per request.
+	// The rate limiter is shared with the retry wrappers so retries count against each provider's limit
+	providerLimiter := handlers.NewProviderRateLimiter(cfg.ProviderRateLimits, cfg.ProviderRateLimitWait)
+	providerFactories, err := newProviderFactories(cfg, providerLimiter)
 	if err != nil {
 		log.Fatalf("Failed to initialize AI provider: %v", err)
 	}
+	defaultProvider := cfg.DefaultAIProvider
+	if defaultProvider == "" {
+		defaultProvider = "openai"
+	}
+	if _, ok := providerFactories[defaultProvider]; !ok {
+		log.Fatalf("Unsupported AI provider: %s", cfg.DefaultAIProvider)
+	}
 
 	// Initialize Orchestration Service
 	log.Println("Initializing orchestration service...")
-	orchestration, err := handlers.NewOrchestrationService(mcpClient, aiProvider)
+	orchestration, err := handlers.NewOrchestrationService(mcpClient, providerFactories, defaultProvider, cfg.MaxToolIterations, cfg.ToolCacheTTL)
 	if err != nil {
 		log.Fatalf("Failed to initialize orchestration service: %v", err)
 	}
+	log.Printf("AI providers available: %v (default: %s)", orchestration.ProviderNames(), defaultProvider)
+	orchestration.SetProviderRateLimiter(providerLimiter)
+	orchestration.SetHistoryBudgets(handlers.NewHistoryBudgets(cfg.HistoryTokenBudget, cfg.HistoryTokenBudgets))
+	orchestration.SetDefaultBlueprints(cfg.DefaultBlueprints)
+	orchestration.SetCacheableToolPrefixes(cfg.CacheableToolPrefixes)
+	orchestration.SetBlueprintCacheTTL(cfg.BlueprintCacheTTL)
+	orchestration.SetBlueprintFilter(handlers.NewBlueprintFilter(cfg.BlueprintAllowedCategories, cfg.BlueprintDeniedCategories))
+	orchestration.SetBlockDeprecatedBlueprints(cfg.BlockDeprecatedBlueprints)
+	orchestration.SetToolFilter(handlers.NewToolFilter(cfg.MCPAllowedTools, cfg.MCPDeniedTools))
+	if cfg.MaintenanceMode {
+		orchestration.SetMaintenanceMode(true)
+		log.Println("Starting in maintenance mode: mutating tools are disabled")
+	}
+	orchestration.StartToolRefresher(cfg.MCPToolRefresh)
+	if cfg.MongoDBURI != "" {
+		connectCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		repo, err := store.NewMongoRepository(connectCtx, cfg.MongoDBURI, cfg.MongoDBDatabase)
+		cancel()
+		if err != nil {
+			log.Fatalf("Failed to initialize conversation store: %v", err)
+		}
+		defer repo.Close(context.Background())
+		orchestration.SetConversationRepository(repo)
+		log.Printf("Conversation sessions stored in MongoDB database %s", cfg.MongoDBDatabase)
+	}
+	for toolName, fields := range cfg.ToolResultStripFields {
+		orchestration.RegisterToolResultHook(toolName, handlers.StripJSONFieldsHook(fields))
+	}
 
 	// Initialize HTTP handler
-	handler := handlers.NewHandler(orchestration)
+	chatLimiter := handlers.NewChatLimiter(cfg.MaxConcurrentChats, cfg.ChatQueueTimeout)
+	handler := handlers.NewHandler(orchestration, chatLimiter)
+	handler.SetAdminConfig(cfg.AdminToken, cfg.Effective())
 
 	// Setup Gin router
 	if cfg.ServerHost != "localhost" && cfg.ServerHost != "127.0.0.1" {
 		gin.SetMode(gin.ReleaseMode)
 	}
-	
-	router := gin.Default()
 
-	// Setup CORS
-	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   cfg.AllowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	})
+	router := gin.New()
+	router.Use(handlers.TraceMiddleware())
+	router.Use(handlers.RequestLogger(logger), gin.Recovery())
 
-	router.Use(func(c *gin.Context) {
-		corsHandler.HandlerFunc(c.Writer, c.Request)
-		c.Next()
-	})
+	// Setup CORS
+	router.Use(newCORSMiddleware(cfg.AllowedOrigins, cfg.CredentialedOrigins))
 
 	// Setup routes
 	handlers.SetupRoutes(router, handler)
@@ -101,6 +138,9 @@ func main() {
 	log.Println("  POST /api/v1/chat       - Send chat prompts")
 	log.Println("  GET  /api/v1/health     - Health check")
 	log.Println("  GET  /api/v1/tools      - List available tools")
+	log.Println("  POST /api/v1/tools/refresh - Re-read tools from the MCP server")
+	log.Println("  GET  /api/v1/mcp/resources - List MCP resources")
+	log.Println("  GET  /api/v1/mcp/prompts   - List MCP prompts")
 
 	// Setup graceful shutdown
 	quit := make(chan os.Signal, 1)
@@ -115,8 +155,161 @@ func main() {
 	// Wait for interrupt signal
 	<-quit
 	log.Println("Shutting down server...")
-	
+
 	// Cleanup
+	if err := orchestration.Close(); err != nil {
+		log.Printf("Error closing AI providers: %v", err)
+	}
 	mcpClient.Close()
 	log.Println("Server stopped")
 }
+
+// newProviderFactories returns a factory for every AI provider whose API key (or, for Bedrock, region
+// and for Ollama, model) is configured.
+// Each factory falls back to the provider's configured model when no model is given
+func newProviderFactories(cfg *config.Config, limiter ai.RateLimiter) (map[string]ai.ProviderFactory, error) {
+	factories := make(map[string]ai.ProviderFactory)
+
+	if cfg.OpenAIAPIKey != "" {
+		factories["openai"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.OpenAIModel
+			}
+			return ai.NewOpenAIProvider(cfg.OpenAIAPIKey, model, cfg.OpenAIReasoningEffort)
+		}
+	}
+	if cfg.AnthropicAPIKey != "" {
+		factories["anthropic"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.AnthropicModel
+			}
+			return ai.NewAnthropicProvider(cfg.AnthropicAPIKey, model)
+		}
+	}
+	if cfg.GeminiAPIKey != "" {
+		format, err := ai.ParseToolCallFormat(cfg.GeminiToolCallFormat)
+		if err != nil {
+			return nil, err
+		}
+		factories["gemini"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.GeminiModel
+			}
+			return ai.NewGeminiProvider(cfg.GeminiAPIKey, model, format)
+		}
+	}
+	if cfg.GleanAPIKey != "" {
+		format, err := ai.ParseToolCallFormat(cfg.GleanToolCallFormat)
+		if err != nil {
+			return nil, err
+		}
+		factories["glean"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.GleanModel
+			}
+			return ai.NewGleanProvider(cfg.GleanAPIKey, cfg.GleanInstance, model, format)
+		}
+	}
+
+	if cfg.BedrockRegion != "" {
+		factories["bedrock"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.BedrockModel
+			}
+			return ai.NewBedrockProvider(cfg.BedrockRegion, model)
+		}
+	}
+
+	if cfg.OllamaModel != "" || cfg.DefaultAIProvider == "ollama" {
+		factories["ollama"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.OllamaModel
+			}
+			return ai.NewOllamaProvider(cfg.OllamaBaseURL, model)
+		}
+	}
+
+	if cfg.CohereAPIKey != "" {
+		factories["cohere"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.CohereModel
+			}
+			return ai.NewCohereProvider(cfg.CohereAPIKey, model)
+		}
+	}
+	if cfg.MistralAPIKey != "" {
+		factories["mistral"] = func(model string) (ai.Provider, error) {
+			if model == "" {
+				model = cfg.MistralModel
+			}
+			return ai.NewMistralProvider(cfg.MistralAPIKey, model)
+		}
+	}
+
+	// In locked-down deployments only allowlisted providers may be used, even if other keys are set
+	for name := range factories {
+		if !cfg.ProviderEnabled(name) {
+			log.Printf("AI provider %s is configured but not in ENABLED_PROVIDERS; it is disabled", name)
+			delete(factories, name)
+		}
+	}
+
+	// Retry transient provider errors with exponential backoff, taking a rate-limit token per retry
+	retry := ai.RetryConfig{
+		MaxAttempts: cfg.ProviderRetryAttempts,
+		BaseDelay:   cfg.ProviderRetryBaseDelay,
+		Limiter:     limiter,
+	}
+	for name, factory := range factories {
+		factories[name] = func(model string) (ai.Provider, error) {
+			provider, err := factory(model)
+			if err != nil {
+				return nil, err
+			}
+			return ai.WithRetry(provider, retry), nil
+		}
+	}
+
+	return factories, nil
+}
+
+// newCORSMiddleware serves credentialed CORS (reflected origin plus
+// Access-Control-Allow-Credentials) to the credentialed origins and plain,
+// non-credentialed CORS to the other allowed origins
+func newCORSMiddleware(allowedOrigins, credentialedOrigins []string) gin.HandlerFunc {
+	options := cors.Options{
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", handlers.TraceIDHeader, "traceparent"},
+		ExposedHeaders: []string{"Link", handlers.TraceIDHeader},
+		MaxAge:         300,
+	}
+
+	publicOptions := options
+	publicOptions.AllowedOrigins = allowedOrigins
+	publicCORS := cors.New(publicOptions)
+
+	credentialedOptions := options
+	credentialedOptions.AllowedOrigins = credentialedOrigins
+	credentialedOptions.AllowCredentials = true
+	credentialedCORS := cors.New(credentialedOptions)
+
+	credentialed := make(map[string]bool, len(credentialedOrigins))
+	for _, origin := range credentialedOrigins {
+		credentialed[origin] = true
+	}
+
+	return func(c *gin.Context) {
+		corsHandler := publicCORS
+		if credentialed[c.GetHeader("Origin")] {
+			corsHandler = credentialedCORS
+		}
+		corsHandler.HandlerFunc(c.Writer, c.Request)
+
+		// Preflight requests are fully answered by the CORS handler
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}